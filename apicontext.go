@@ -3,14 +3,16 @@ package apicontext
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -19,9 +21,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/PaesslerAG/jsonpath"
 	"github.com/cucumber/godog"
 	"github.com/xeipuuv/gojsonschema"
+	"google.golang.org/grpc/status"
 )
 
 // The defaults path to json schema files for validating the responses.
@@ -29,35 +31,60 @@ const defaultSchemasPath = "schemas"
 
 // ApiContext main struct
 type ApiContext struct {
-	baseURL         string
-	jSONSchemasPath string
-	debug           bool
-	client          *http.Client
-	headers         map[string]string
-	queryParams     map[string]string
-	lastResponse    *ApiResponse
-	lastRequest     *http.Request
-	scope           map[string]string
-}
-
-// ApiResponse Struct that wraps an API response.
-// It contains common accessed fields like Status Code and the Payload as well as access to the raw http.Response object
-type ApiResponse struct {
-	StatusCode  int
-	Body        string
-	ResponseObj *http.Response
+	baseURL            string
+	baseURLs           map[string]string
+	baseURLName        string
+	jSONSchemasPath    string
+	fixturesPath       string
+	debug              bool
+	client             *http.Client
+	defaultHeaders     http.Header
+	defaultQueryParams url.Values
+	featureHeaders     http.Header
+	featureQueryParams url.Values
+	headers            http.Header
+	queryParams        url.Values
+	pathParams         map[string]string
+	lastResponse       *ApiResponse
+	lastRequest        *http.Request
+	history            []exchange
+	eventStream        *eventStream
+	webSocket          *webSocket
+	grpcStatus         *status.Status
+	grpcDescriptorSets []string
+	datasetRows        []datasetRow
+	burstResults       []burstResult
+	scope              *Scope
+	strictScope        bool
+	numberTolerance    float64
+	currentFeature     string
+	currentStep        string
+	softAssertions     bool
+	assertionFailures  []assertionFailure
+
+	snapshotsPath         string
+	snapshotMasks         []string
+	scenarioSnapshotMasks []string
+	updateSnapshots       bool
 }
 
 // New Creates a new instance of the API Context
 func New(baseURL string) *ApiContext {
 	return &ApiContext{
-		baseURL:         baseURL,
-		client:          &http.Client{},
-		headers:         map[string]string{},
-		queryParams:     map[string]string{},
-		debug:           false,
-		jSONSchemasPath: defaultSchemasPath,
-		scope:           map[string]string{},
+		baseURL:            baseURL,
+		baseURLs:           map[string]string{},
+		client:             &http.Client{},
+		defaultHeaders:     http.Header{},
+		defaultQueryParams: url.Values{},
+		featureHeaders:     http.Header{},
+		featureQueryParams: url.Values{},
+		headers:            http.Header{},
+		queryParams:        url.Values{},
+		pathParams:         map[string]string{},
+		debug:              false,
+		jSONSchemasPath:    defaultSchemasPath,
+		snapshotsPath:      defaultSnapshotsPath,
+		scope:              newScope(),
 	}
 }
 
@@ -68,6 +95,13 @@ func (ctx *ApiContext) WithBaseURL(url string) *ApiContext {
 	return ctx
 }
 
+// WithNamedBaseURL Registers an additional base URL, that can be selected in a scenario by its name.
+func (ctx *ApiContext) WithNamedBaseURL(name string, url string) *ApiContext {
+	ctx.baseURLs[name] = url
+
+	return ctx
+}
+
 // WithDebug Configures debug mode
 func (ctx *ApiContext) WithDebug(debug bool) *ApiContext {
 	ctx.debug = debug
@@ -75,154 +109,394 @@ func (ctx *ApiContext) WithDebug(debug bool) *ApiContext {
 	return ctx
 }
 
+// WithStrictScope Configures strict mode, where referencing an undefined scope variable without a default value is an error.
+func (ctx *ApiContext) WithStrictScope(strict bool) *ApiContext {
+	ctx.strictScope = strict
+	return ctx
+}
+
 // WithJSONSchemasPath Specifies the path to JSON schema files for doing response validation
 func (ctx *ApiContext) WithJSONSchemasPath(path string) *ApiContext {
 	ctx.jSONSchemasPath = path
 	return ctx
 }
 
+// WithDefaultHeaders Configures headers that are sent with every request.
+// They survive the reset between scenarios and can be overridden or removed within a scenario.
+func (ctx *ApiContext) WithDefaultHeaders(headers map[string]string) *ApiContext {
+	for name, value := range headers {
+		ctx.defaultHeaders.Set(name, value)
+		ctx.headers.Set(name, value)
+	}
+
+	return ctx
+}
+
+// WithDefaultQueryParams Configures query params that are sent with every request.
+// They survive the reset between scenarios and can be overridden or removed within a scenario.
+func (ctx *ApiContext) WithDefaultQueryParams(params map[string]string) *ApiContext {
+	for name, value := range params {
+		ctx.defaultQueryParams.Set(name, value)
+		ctx.queryParams.Set(name, value)
+	}
+
+	return ctx
+}
+
+// WithFixturesPath Specifies the path used to resolve relative file paths in form bodies, datasets and expected files
+func (ctx *ApiContext) WithFixturesPath(path string) *ApiContext {
+	ctx.fixturesPath = path
+	return ctx
+}
+
 // InitializeScenario this function should be called when starting the Test suite, to register the available steps.
 func (ctx *ApiContext) InitializeScenario(s *godog.ScenarioContext) {
-	s.BeforeScenario(ctx.reset)
-
+	s.Before(func(goCtx context.Context, sc *godog.Scenario) (context.Context, error) {
+		ctx.reset(sc)
+		return goCtx, nil
+	})
+	s.After(func(goCtx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		ctx.closeEventStream()
+		ctx.closeWebSocket()
+		return goCtx, nil
+	})
+	s.After(ctx.reportAssertionFailures)
+	s.StepContext().Before(ctx.beforeStep)
+
+	s.Step(`^I collect assertion failures$`, ctx.ICollectAssertionFailures)
+
+	s.Step(`^I use the "([^"]*)" base URL$`, ctx.IUseTheBaseURL)
 	s.Step(`^I set header "([^"]*)" with value "([^"]*)"$`, ctx.ISetHeaderWithValue)
 	s.Step(`^I set headers to:$`, ctx.ISetHeadersTo)
+	s.Step(`^I add header "([^"]*)" with value "([^"]*)"$`, ctx.IAddHeaderWithValue)
+	s.Step(`^I set persistent header "([^"]*)" with value "([^"]*)"$`, ctx.ISetPersistentHeaderWithValue)
+	s.Step(`^I set persistent headers to:$`, ctx.ISetPersistentHeadersTo)
+	s.Step(`^I remove header "([^"]*)"$`, ctx.IRemoveHeader)
 	s.Step(`^I send "([^"]*)" request to "([^"]*)" with form body::$`, ctx.ISendRequestToWithFormBody)
 	s.Step(`^I send "([^"]*)" request to "([^"]*)" with body:$`, ctx.ISendRequestToWithBody)
+	s.Step(`^I send "([^"]*)" request to "([^"]*)" with body from file for each row in "([^"]*)"$`, ctx.ISendRequestToWithBodyFromFileForEachRowIn)
+	s.Step(`^I send "([^"]*)" request to "([^"]*)" with body for each row in "([^"]*)":$`, ctx.ISendRequestToWithBodyForEachRowIn)
+	s.Step(`^I send "([^"]*)" request to "([^"]*)" for each row in "([^"]*)"$`, ctx.ISendRequestToForEachRowIn)
+	s.Step(`^I send (\d+) concurrent "([^"]*)" requests to "([^"]*)" with body:$`, ctx.ISendConcurrentRequestsToWithBody)
+	s.Step(`^I send (\d+) concurrent "([^"]*)" requests to "([^"]*)"$`, ctx.ISendConcurrentRequestsTo)
+	s.Step(`^I send "([^"]*)" request to "([^"]*)" as "([^"]*)" with body:$`, ctx.ISendRequestToAsWithBody)
+	s.Step(`^I send "([^"]*)" request to "([^"]*)" as "([^"]*)"$`, ctx.ISendRequestToAs)
 	s.Step(`^I send "([^"]*)" request to "([^"]*)"$`, ctx.ISendRequestTo)
+	s.Step(`^I call grpc method "([^"]*)" with json:$`, ctx.ICallGRPCMethodWithJSON)
+	s.Step(`^I call grpc method "([^"]*)"$`, ctx.ICallGRPCMethod)
 	s.Step(`^I set query param "([^"]*)" with value "([^"]*)"$`, ctx.ISetQueryParamWithValue)
 	s.Step(`^I set query params to:$`, ctx.ISetQueryParamsTo)
-	s.Step(`^The response code should be (\d+)$`, ctx.TheResponseCodeShouldBe)
-	s.Step(`^The response should be a valid json$`, ctx.TheResponseShouldBeAValidJSON)
-	s.Step(`^The response should match json:$`, ctx.TheResponseShouldMatchJSON)
-	s.Step(`^The response header "([^"]*)" should have value ([^"]*)$`, ctx.TheResponseHeaderShouldHaveValue)
-	s.Step(`^The response should match json schema "([^"]*)"$`, ctx.TheResponseShouldMatchJsonSchema)
-	s.Step(`^The json path "([^"]*)" should have value "([^"]*)"$`, ctx.TheJSONPathShouldHaveValue)
-	s.Step(`^The json path "([^"]*)" should match "([^"]*)"$`, ctx.TheJSONPathShouldMatch)
-	s.Step(`^The json path "([^"]*)" should have count "([^"]*)"$`, ctx.TheJSONPathHaveCount)
-	s.Step(`^The json path "([^"]*)" should be present"$`, ctx.TheJSONPathShouldBePresent)
-	s.Step(`^The response body should contain "([^"]*)"$`, ctx.TheResponseBodyShouldContain)
-	s.Step(`^The response body should match "([^"]*)"$`, ctx.TheResponseBodyShouldMatch)
+	s.Step(`^I set path param "([^"]*)" with value "([^"]*)"$`, ctx.ISetPathParamWithValue)
+	s.Step(`^I set path params to:$`, ctx.ISetPathParamsTo)
+	s.Step(`^I add query param "([^"]*)" with value "([^"]*)"$`, ctx.IAddQueryParamWithValue)
+	s.Step(`^I set persistent query param "([^"]*)" with value "([^"]*)"$`, ctx.ISetPersistentQueryParamWithValue)
+	s.Step(`^I remove query param "([^"]*)"$`, ctx.IRemoveQueryParam)
+	s.Step(`^The response code should be (\d+)$`, ctx.softAssertion(ctx.TheResponseCodeShouldBe))
+	s.Step(`^The response code of response "([^"]*)" should be (\d+)$`, ctx.softAssertion(ctx.TheResponseCodeOfResponseShouldBe))
+	s.Step(`^The response code for each row should be (\d+)$`, ctx.softAssertion(ctx.TheResponseCodeForEachRowShouldBe))
+	s.Step(`^[Aa]ll responses should have status (\d+)$`, ctx.softAssertion(ctx.AllResponsesShouldHaveStatus))
+	s.Step(`^[Ee]xactly (\d+) responses? should have status (\d+)$`, ctx.softAssertion(ctx.ExactlyResponsesShouldHaveStatus))
+	s.Step(`^[Pp](\d+) response time should be below (\d+) ?ms$`, ctx.softAssertion(ctx.PercentileResponseTimeShouldBeBelow))
+	s.Step(`^The grpc status code should be "([^"]*)"$`, ctx.softAssertion(ctx.TheGRPCStatusCodeShouldBe))
+	s.Step(`^The grpc status message should be "([^"]*)"$`, ctx.softAssertion(ctx.TheGRPCStatusMessageShouldBe))
+	s.Step(`^The response body should have sha256 "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseBodyShouldHaveSHA256))
+	s.Step(`^The response size should be (\d+) bytes$`, ctx.softAssertion(ctx.TheResponseSizeShouldBe))
+	s.Step(`^The response body should equal file "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseBodyShouldEqualFile))
+	s.Step(`^The response image should have dimensions (\d+)x(\d+)$`, ctx.softAssertion(ctx.TheResponseImageShouldHaveDimensions))
+	s.Step(`^The response body should be detected as "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseBodyShouldBeDetectedAs))
+	s.Step(`^The response should be a valid json$`, ctx.softAssertion(ctx.TheResponseShouldBeAValidJSON))
+	s.Step(`^The response should match json:$`, ctx.softAssertion(ctx.TheResponseShouldMatchJSON))
+	s.Step(`^The response header "([^"]*)" should have value "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseHeaderShouldHaveValue))
+	s.Step(`^The response header "([^"]*)" should have value ([^"]*)$`, ctx.softAssertion(ctx.TheResponseHeaderShouldHaveValue))
+	s.Step(`^The response header "([^"]*)" should contain "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseHeaderShouldContain))
+	s.Step(`^The response header "([^"]*)" should match "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseHeaderShouldMatch))
+	s.Step(`^The response header "([^"]*)" should be present$`, ctx.softAssertion(ctx.TheResponseHeaderShouldBePresent))
+	s.Step(`^The response header "([^"]*)" should not be present$`, ctx.softAssertion(ctx.TheResponseHeaderShouldNotBePresent))
+	s.Step(`^The response content type should be "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseContentTypeShouldBe))
+	s.Step(`^The response content encoding should be "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseContentEncodingShouldBe))
+	s.Step(`^The response compression ratio should be at least (\d+(?:\.\d+)?)$`, ctx.softAssertion(ctx.TheResponseCompressionRatioShouldBeAtLeast))
+	s.Step(`^I mask json path "([^"]*)" in snapshots$`, ctx.IMaskJSONPathInSnapshots)
+	s.Step(`^The response should match snapshot "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseShouldMatchSnapshot))
+	s.Step(`^The response should match json schema "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseShouldMatchJsonSchema))
+	s.Step(`^The json path "([^"]*)" should have value "([^"]*)"$`, ctx.softAssertion(ctx.TheJSONPathShouldHaveValue))
+	s.Step(`^The json path "([^"]*)" should have number value "([^"]*)"$`, ctx.softAssertion(ctx.TheJSONPathShouldHaveNumberValue))
+	s.Step(`^The json path "([^"]*)" should have string value "([^"]*)"$`, ctx.softAssertion(ctx.TheJSONPathShouldHaveStringValue))
+	s.Step(`^The json path "([^"]*)" for each row should have value "([^"]*)"$`, ctx.softAssertion(ctx.TheJSONPathForEachRowShouldHaveValue))
+	s.Step(`^The json path "([^"]*)" of response "([^"]*)" should have value "([^"]*)"$`, ctx.softAssertion(ctx.TheJSONPathOfResponseShouldHaveValue))
+	s.Step(`^The json path "([^"]*)" of response "([^"]*)" should be (greater than|less than|the same as|different from) in response "([^"]*)"$`, ctx.softAssertion(ctx.TheJSONPathOfResponseShouldCompareTo))
+	s.Step(`^The json path "([^"]*)" should match "([^"]*)"$`, ctx.softAssertion(ctx.TheJSONPathShouldMatch))
+	s.Step(`^The json path "([^"]*)" should have count "([^"]*)"$`, ctx.softAssertion(ctx.TheJSONPathHaveCount))
+	s.Step(`^The json path "([^"]*)" should be present"$`, ctx.softAssertion(ctx.TheJSONPathShouldBePresent))
+	s.Step(`^The jq expression "(.*)" should evaluate to "(.*)"$`, ctx.softAssertion(ctx.TheJQExpressionShouldEvaluateTo))
+	s.Step(`^The html element "([^"]*)" should be present$`, ctx.softAssertion(ctx.TheHTMLElementShouldBePresent))
+	s.Step(`^The html element "([^"]*)" should not be present$`, ctx.softAssertion(ctx.TheHTMLElementShouldNotBePresent))
+	s.Step(`^The html element "([^"]*)" should have count (\d+)$`, ctx.softAssertion(ctx.TheHTMLElementShouldHaveCount))
+	s.Step(`^The html element "([^"]*)" should have text "([^"]*)"$`, ctx.softAssertion(ctx.TheHTMLElementShouldHaveText))
+	s.Step(`^The html element "([^"]*)" text should match "([^"]*)"$`, ctx.softAssertion(ctx.TheHTMLElementTextShouldMatch))
+	s.Step(`^The html element "([^"]*)" should have attribute "([^"]*)" with value "([^"]*)"$`, ctx.softAssertion(ctx.TheHTMLElementShouldHaveAttribute))
+	s.Step(`^The response body should contain "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseBodyShouldContain))
+	s.Step(`^The response body should match "([^"]*)"$`, ctx.softAssertion(ctx.TheResponseBodyShouldMatch))
+	s.Step(`^I open an event stream to "([^"]*)"$`, ctx.IOpenAnEventStreamTo)
+	s.Step(`^I should receive an event "([^"]*)" within (\d+) seconds$`, ctx.IShouldReceiveAnEventWithin)
+	s.Step(`^I should receive an event "([^"]*)" within (\d+) seconds with json:$`, ctx.IShouldReceiveAnEventWithinWithJSON)
+	s.Step(`^I close the event stream$`, ctx.ICloseTheEventStream)
+	s.Step(`^I connect to websocket "([^"]*)"$`, ctx.IConnectToWebSocket)
+	s.Step(`^I send websocket message with json:$`, ctx.ISendWebSocketMessageWithJSON)
+	s.Step(`^I send websocket message "(.*)"$`, ctx.ISendWebSocketMessage)
+	s.Step(`^I should receive a websocket message with json path "([^"]*)" having value "([^"]*)" within (\d+) seconds$`, ctx.IShouldReceiveAWebSocketMessageWithJSONPathWithin)
+	s.Step(`^I should receive a websocket message matching "(.*)" within (\d+) seconds$`, ctx.IShouldReceiveAWebSocketMessageMatchingWithin)
+	s.Step(`^I close the websocket with code (\d+)$`, ctx.ICloseTheWebSocketWithCode)
+	s.Step(`^The websocket should be closed with code (\d+) within (\d+) seconds$`, ctx.TheWebSocketShouldBeClosedWithCodeWithin)
+	s.Step(`^I save the response body to "([^"]*)"$`, ctx.ISaveTheResponseBodyTo)
 	s.Step(`^I wait for (\d+) seconds$`, ctx.WaitForSomeTime)
 	s.Step(`^I store data in scope variable "([^"]*)" with value "([^"]*)"`, ctx.StoreScopeData)
-	s.Step(`^I store the value of response header "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreResponseHeader)
-	s.Step(`^I store the value of body path "([^"]*)" as "([^"]*)" in scenario scope$`, ctx.StoreJsonPathValue)
-	s.Step(`^The scope variable "([^"]*)" should have value "([^"]*)"$`, ctx.TheScopeVariableShouldHaveValue)
+	s.Step(`^I store data in (scenario|feature|global) scope variable "([^"]*)" with value "([^"]*)"$`, ctx.StoreScopeDataIn)
+	s.Step(`^I store the value of response header "([^"]*)" as "([^"]*)" in (scenario|feature|global) scope$`, ctx.StoreResponseHeaderIn)
+	s.Step(`^I store the value of body path "([^"]*)" as "([^"]*)" in (scenario|feature|global) scope$`, ctx.StoreJsonPathValueIn)
+	s.Step(`^I store the value of event path "([^"]*)" as "([^"]*)" in (scenario|feature|global) scope$`, ctx.StoreEventPathValueIn)
+	s.Step(`^I store the value of websocket message path "([^"]*)" as "([^"]*)" in (scenario|feature|global) scope$`, ctx.StoreWebSocketMessagePathValueIn)
+	s.Step(`^I store the text of html element "([^"]*)" as "([^"]*)" in (scenario|feature|global) scope$`, ctx.StoreHTMLElementTextIn)
+	s.Step(`^I store the attribute "([^"]*)" of html element "([^"]*)" as "([^"]*)" in (scenario|feature|global) scope$`, ctx.StoreHTMLElementAttributeIn)
+	s.Step(`^I store the result of jq expression "(.*)" as "([^"]*)" in (scenario|feature|global) scope$`, ctx.StoreJQResultIn)
+	s.Step(`^The scope variable "([^"]*)" should have value "([^"]*)"$`, ctx.softAssertion(ctx.TheScopeVariableShouldHaveValue))
+	s.Step(`^The scope variable "([^"]*)" should match json:$`, ctx.softAssertion(ctx.TheScopeVariableShouldMatchJSON))
 }
 
-// reset Reset the internal state of the API context
-func (ctx *ApiContext) reset(*godog.Scenario) {
-	ctx.headers = make(map[string]string)
-	ctx.queryParams = make(map[string]string)
+// reset Reset the internal state of the API context.
+// The scenario scope is always cleared, while the feature scope and the persistent headers and query params
+// are only cleared when the scenario belongs to a different feature.
+func (ctx *ApiContext) reset(sc *godog.Scenario) {
+	if sc.Uri != ctx.currentFeature {
+		ctx.scope.clear(FeatureScope)
+		ctx.featureHeaders = http.Header{}
+		ctx.featureQueryParams = url.Values{}
+		ctx.currentFeature = sc.Uri
+	}
+
+	ctx.headers = http.Header(mergeValues(ctx.defaultHeaders, ctx.featureHeaders))
+	ctx.queryParams = url.Values(mergeValues(ctx.defaultQueryParams, ctx.featureQueryParams))
+	ctx.pathParams = make(map[string]string)
+	ctx.scenarioSnapshotMasks = nil
+	ctx.baseURLName = ""
 	ctx.lastResponse = nil
 	ctx.lastRequest = nil
+	ctx.history = nil
+	ctx.grpcStatus = nil
+	ctx.datasetRows = nil
+	ctx.burstResults = nil
+	ctx.closeEventStream()
+	ctx.closeWebSocket()
+	ctx.scope.clear(ScenarioScope)
+	ctx.beforeSoftAssertionsScenario(sc)
 }
 
-// ISetHeadersTo This step sets the request headers using a datatable as source.
-// It allows to define multiple headers at the same time.
-func (ctx *ApiContext) ISetHeadersTo(dt *godog.Table) error {
-	for i := 0; i < len(dt.Rows); i++ {
-		ctx.headers[dt.Rows[i].Cells[0].Value] = ctx.ReplaceScopeVariables(dt.Rows[i].Cells[1].Value)
+// IUseTheBaseURL Selects one of the named base URLs for the requests of the current scenario.
+func (ctx *ApiContext) IUseTheBaseURL(name string) error {
+	if _, ok := ctx.baseURLs[name]; !ok {
+		return fmt.Errorf("base URL %q is not defined", name)
 	}
 
+	ctx.baseURLName = name
 	return nil
 }
 
-// ISetHeaderWithValue Step that add a new header to the current request.
+// requestURL Builds the URL of a request, using the base URL selected for the scenario.
+// The uri can be a template like "/users/{id}", whose variables are resolved from the path params and then from the scope.
+func (ctx *ApiContext) requestURL(uri string) (string, error) {
+	baseURL := ctx.baseURL
+	if ctx.baseURLName != "" {
+		baseURL = ctx.baseURLs[ctx.baseURLName]
+	}
+
+	path, err := expandURITemplate(uri, ctx.lookupPathParam)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%s", baseURL, path), nil
+}
+
+// lookupPathParam Resolves a URI template variable from the path params, falling back to the scope variables.
+func (ctx *ApiContext) lookupPathParam(name string) (string, bool) {
+	if value, ok := ctx.pathParams[name]; ok {
+		return value, true
+	}
+
+	if value, ok := ctx.scope.Get(name); ok {
+		return stringifyJSON(value), true
+	}
+
+	return "", false
+}
+
+// ISetHeadersTo This step sets the request headers using a datatable as source.
+// It allows to define multiple headers at the same time. Repeating a header name in the table adds multiple values.
+func (ctx *ApiContext) ISetHeadersTo(dt *godog.Table) error {
+	return setValuesFromTable(dt, ctx.replaceScopeVariables, func(name string, value string, first bool) {
+		if first {
+			ctx.headers.Set(name, value)
+		} else {
+			ctx.headers.Add(name, value)
+		}
+	})
+}
+
+// ISetHeaderWithValue Step that add a new header to the current request, replacing any existing values.
 func (ctx *ApiContext) ISetHeaderWithValue(name string, value string) error {
-	ctx.headers[name] = value
+	value, err := ctx.replaceScopeVariables(value)
+	if err != nil {
+		return err
+	}
+
+	ctx.headers.Set(name, value)
 	return nil
 }
 
-// ISetQueryParamWithValue Adds a new query param to the request
-func (ctx *ApiContext) ISetQueryParamWithValue(name string, value string) error {
-	ctx.queryParams[name] = ctx.ReplaceScopeVariables(value)
+// IAddHeaderWithValue Adds a value to a header of the current request, keeping the existing values.
+func (ctx *ApiContext) IAddHeaderWithValue(name string, value string) error {
+	value, err := ctx.replaceScopeVariables(value)
+	if err != nil {
+		return err
+	}
+
+	ctx.headers.Add(name, value)
 	return nil
 }
 
-// ISetQueryParamsTo Set query params from a Data Table
-func (ctx *ApiContext) ISetQueryParamsTo(dt *godog.Table) error {
-	for i := 0; i < len(dt.Rows); i++ {
-		ctx.queryParams[dt.Rows[i].Cells[0].Value] = ctx.ReplaceScopeVariables(dt.Rows[i].Cells[1].Value)
+// ISetPersistentHeaderWithValue Adds a header that is kept for the remaining scenarios of the current feature.
+func (ctx *ApiContext) ISetPersistentHeaderWithValue(name string, value string) error {
+	value, err := ctx.replaceScopeVariables(value)
+	if err != nil {
+		return err
 	}
 
+	ctx.featureHeaders.Set(name, value)
+	ctx.headers.Set(name, value)
 	return nil
 }
 
-// ISendRequestTo Sends a request to the specified endpoint using the specified method.
-func (ctx *ApiContext) ISendRequestTo(method, uri string) error {
-	reqURL := fmt.Sprintf("%s%s", ctx.baseURL, uri)
+// ISetPersistentHeadersTo Sets headers from a Data Table that are kept for the remaining scenarios of the current feature.
+func (ctx *ApiContext) ISetPersistentHeadersTo(dt *godog.Table) error {
+	return setValuesFromTable(dt, ctx.replaceScopeVariables, func(name string, value string, first bool) {
+		if first {
+			ctx.featureHeaders.Set(name, value)
+			ctx.headers.Set(name, value)
+		} else {
+			ctx.featureHeaders.Add(name, value)
+			ctx.headers.Add(name, value)
+		}
+	})
+}
 
-	req, err := http.NewRequest(method, reqURL, nil)
+// IRemoveHeader Removes a header from the requests of the current scenario, including default and persistent headers.
+func (ctx *ApiContext) IRemoveHeader(name string) error {
+	ctx.headers.Del(name)
+	return nil
+}
 
+// ISetQueryParamWithValue Adds a new query param to the request, replacing any existing values.
+func (ctx *ApiContext) ISetQueryParamWithValue(name string, value string) error {
+	value, err := ctx.replaceScopeVariables(value)
 	if err != nil {
 		return err
 	}
 
-	// Add headers to request
-	for name, value := range ctx.headers {
-		req.Header.Set(name, value)
-	}
+	ctx.queryParams.Set(name, value)
+	return nil
+}
 
-	// Add query string to request
-	q := req.URL.Query()
-	for name, value := range ctx.queryParams {
-		q.Add(name, value)
+// IAddQueryParamWithValue Adds a value to a query param, keeping the existing values. Ex: ?tag=a&tag=b
+func (ctx *ApiContext) IAddQueryParamWithValue(name string, value string) error {
+	value, err := ctx.replaceScopeVariables(value)
+	if err != nil {
+		return err
 	}
 
-	req.URL.RawQuery = q.Encode()
-
-	ctx.logRequest(req)
+	ctx.queryParams.Add(name, value)
+	return nil
+}
 
-	ctx.lastRequest = req
-	resp, err := ctx.client.Do(req)
+// ISetQueryParamsTo Set query params from a Data Table. Repeating a param name in the table adds multiple values.
+func (ctx *ApiContext) ISetQueryParamsTo(dt *godog.Table) error {
+	return setValuesFromTable(dt, ctx.replaceScopeVariables, func(name string, value string, first bool) {
+		if first {
+			ctx.queryParams.Set(name, value)
+		} else {
+			ctx.queryParams.Add(name, value)
+		}
+	})
+}
 
+// ISetPersistentQueryParamWithValue Adds a query param that is kept for the remaining scenarios of the current feature.
+func (ctx *ApiContext) ISetPersistentQueryParamWithValue(name string, value string) error {
+	value, err := ctx.replaceScopeVariables(value)
 	if err != nil {
 		return err
 	}
 
-	ctx.logResponse(resp)
-
-	body, err2 := ioutil.ReadAll(resp.Body)
+	ctx.featureQueryParams.Set(name, value)
+	ctx.queryParams.Set(name, value)
+	return nil
+}
 
-	if err2 != nil {
-		return err2
-	}
+// IRemoveQueryParam Removes a query param from the requests of the current scenario, including default and persistent params.
+func (ctx *ApiContext) IRemoveQueryParam(name string) error {
+	ctx.queryParams.Del(name)
+	return nil
+}
 
-	ctx.lastResponse = &ApiResponse{
-		StatusCode:  resp.StatusCode,
-		ResponseObj: resp,
-		Body:        string(body),
+// ISetPathParamWithValue Sets the value of a URI template variable. Ex: "id" for "/users/{id}"
+func (ctx *ApiContext) ISetPathParamWithValue(name string, value string) error {
+	value, err := ctx.replaceScopeVariables(value)
+	if err != nil {
+		return err
 	}
 
+	ctx.pathParams[name] = value
 	return nil
 }
 
-// ISendRequestToWithFormBody Send a request with json body. Ex: a POST request.
-func (ctx *ApiContext) ISendRequestToWithFormBody(method, uri string, requestBodyTable *godog.Table) error {
+// ISetPathParamsTo Set path params from a Data Table
+func (ctx *ApiContext) ISetPathParamsTo(dt *godog.Table) error {
+	return setValuesFromTable(dt, ctx.replaceScopeVariables, func(name string, value string, _ bool) {
+		ctx.pathParams[name] = value
+	})
+}
 
-	reqURL := fmt.Sprintf("%s%s", ctx.baseURL, uri)
+// ISendRequestTo Sends a request to the specified endpoint using the specified method.
+func (ctx *ApiContext) ISendRequestTo(method, uri string) error {
+	_, err := ctx.Send(method, uri, nil)
+	return err
+}
 
+// ISendRequestToWithFormBody Send a request with json body. Ex: a POST request.
+func (ctx *ApiContext) ISendRequestToWithFormBody(method, uri string, requestBodyTable *godog.Table) error {
 	reqBody := &bytes.Buffer{}
 	w := multipart.NewWriter(reqBody)
 
 	for i := 0; i < len(requestBodyTable.Rows); i++ {
 		key := requestBodyTable.Rows[i].Cells[0].Value
-		value := ctx.ReplaceScopeVariables(requestBodyTable.Rows[i].Cells[1].Value)
 		typeOfField := requestBodyTable.Rows[i].Cells[2].Value
 
 		var fw io.Writer
-		var err error
+		value, err := ctx.replaceScopeVariables(requestBodyTable.Rows[i].Cells[1].Value)
+		if err != nil {
+			return err
+		}
 
 		if typeOfField == "text" {
 			if err = w.WriteField(key, value); err != nil {
 				return err
 			}
 		} else if typeOfField == "file" {
+			if ctx.fixturesPath != "" && !filepath.IsAbs(value) {
+				value = filepath.Join(ctx.fixturesPath, value)
+			}
 			file, err := os.Open(value)
 			if err != nil {
-				panic(err)
+				return fmt.Errorf("cannot open form file: %w", err)
 			}
+			defer file.Close()
 			_, fileName := filepath.Split(value)
 			fw, err = w.CreateFormFile(key, fileName)
 			if err != nil {
@@ -240,61 +514,63 @@ func (ctx *ApiContext) ISendRequestToWithFormBody(method, uri string, requestBod
 		return err
 	}
 
-	req, err := http.NewRequest(method, reqURL, bytes.NewReader(reqBody.Bytes()))
+	req, err := ctx.newRequest(method, uri, bytes.NewReader(reqBody.Bytes()))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", contentType)
-	for name, value := range ctx.headers {
-		req.Header.Set(name, value)
-	}
-
-	ctx.logRequest(req)
 
-	ctx.lastRequest = req
-	resp, err := ctx.client.Do(req)
+	return ctx.sendRequest(req)
+}
 
+// ISendRequestToWithBody Send a request with json body. Ex: a POST request.
+func (ctx *ApiContext) ISendRequestToWithBody(method, uri string, requestBody *godog.DocString) error {
+	jsonBody, err := ctx.replaceScopeVariablesInJSON(requestBody.Content)
 	if err != nil {
 		return err
 	}
 
-	ctx.logResponse(resp)
-
-	body, err2 := ioutil.ReadAll(resp.Body)
+	_, err = ctx.Send(method, uri, bytes.NewBufferString(jsonBody))
+	return err
+}
 
-	if err2 != nil {
-		return err2
+// newRequest Creates a request to the specified endpoint, with the headers and query params of the current scenario.
+func (ctx *ApiContext) newRequest(method, uri string, body io.Reader) (*http.Request, error) {
+	reqURL, err := ctx.requestURL(uri)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx.lastResponse = &ApiResponse{
-		StatusCode:  resp.StatusCode,
-		ResponseObj: resp,
-		Body:        string(body),
+	req, err := http.NewRequest(method, reqURL, body)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
-
-// ISendRequestToWithBody Send a request with json body. Ex: a POST request.
-func (ctx *ApiContext) ISendRequestToWithBody(method, uri string, requestBody *godog.DocString) error {
-
-	reqURL := fmt.Sprintf("%s%s", ctx.baseURL, uri)
-	jsonBody := ctx.ReplaceScopeVariables(requestBody.Content)
-	//todo
-	var jsonBodyBytes = []byte(jsonBody)
-	req, err := http.NewRequest(method, reqURL, bytes.NewBuffer(jsonBodyBytes))
-
-	for name, value := range ctx.headers {
-		req.Header.Set(name, value)
+	// Add headers to request
+	for name, values := range ctx.headers {
+		req.Header[name] = append([]string(nil), values...)
 	}
 
-	if err != nil {
-		return err
+	// Add query string to request
+	q := req.URL.Query()
+	for name, values := range ctx.queryParams {
+		for _, value := range values {
+			q.Add(name, value)
+		}
 	}
 
+	req.URL.RawQuery = q.Encode()
+
+	return req, nil
+}
+
+// sendRequest Sends the request and stores the response as the last response.
+func (ctx *ApiContext) sendRequest(req *http.Request) error {
+	acceptGzip(req)
 	ctx.logRequest(req)
 
 	ctx.lastRequest = req
+	ctx.lastResponse = nil
 	resp, err := ctx.client.Do(req)
 
 	if err != nil {
@@ -303,98 +579,105 @@ func (ctx *ApiContext) ISendRequestToWithBody(method, uri string, requestBody *g
 
 	ctx.logResponse(resp)
 
-	body, err2 := ioutil.ReadAll(resp.Body)
+	rawBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
 
-	if err2 != nil {
-		return err2
+	if err != nil {
+		return err
 	}
 
-	ctx.lastResponse = &ApiResponse{
-		StatusCode:  resp.StatusCode,
-		ResponseObj: resp,
-		Body:        string(body),
-	}
+	ctx.lastResponse = newApiResponse(resp, rawBody)
+	ctx.history = append(ctx.history, exchange{request: req, response: ctx.lastResponse})
 
 	return nil
 }
 
 // TheResponseCodeShouldBe Check if the http status code of the response matches the specified value.
 func (ctx *ApiContext) TheResponseCodeShouldBe(statusCode int) error {
-	if statusCode != ctx.lastResponse.StatusCode {
-		return fmt.Errorf("expected status code to be %d, but actual is %d.\n Response body: %s", statusCode, ctx.lastResponse.StatusCode, ctx.lastResponse.Body)
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	if statusCode != response.StatusCode {
+		return fmt.Errorf("expected status code to be %d, but actual is %d.\n Response body: %s", statusCode, response.StatusCode, response.Body)
 	}
 	return nil
 }
 
 // TheResponseShouldBeAValidJSON checks if the response is a valid JSON.
 func (ctx *ApiContext) TheResponseShouldBeAValidJSON() error {
-	var data interface{}
-	return json.Unmarshal([]byte(ctx.lastResponse.Body), &data)
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	_, err = response.JSON()
+	return err
 }
 
 // TheJSONPathShouldHaveValue Validates if the json object have the expected value at the specified path.
+// The expected value is interpreted with the type of the actual value: numbers are compared by value,
+// while null, objects and arrays are compared with the expected value parsed as JSON.
 func (ctx *ApiContext) TheJSONPathShouldHaveValue(pathExpr string, expectedValue string) error {
-	var jsonData interface{}
-	expectedValue = ctx.ReplaceScopeVariables(expectedValue)
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
+	response, err := ctx.response()
+	if err != nil {
 		return err
 	}
 
-	actualValue, err := jsonpath.Get(pathExpr, jsonData)
+	return ctx.jsonPathShouldHaveValue(response, pathExpr, expectedValue)
+}
 
+// jsonPathShouldHaveValue Validates the value at the specified json path of a response.
+func (ctx *ApiContext) jsonPathShouldHaveValue(response *ApiResponse, pathExpr string, expectedValue string) error {
+	expectedValue, err := ctx.replaceScopeVariables(expectedValue)
 	if err != nil {
 		return err
 	}
 
-	var expectedParsedValue interface{}
-	switch reflect.TypeOf(actualValue).Kind() {
-	case reflect.Bool:
-		expectedParsedValue, err = strconv.ParseBool(expectedValue)
+	actualValue, err := response.JSONPath(pathExpr)
 
-		if err != nil {
-			return err
-		}
+	if err != nil {
+		return err
+	}
 
-	case reflect.Float64:
-		expectedParsedValue, err = strconv.ParseFloat(expectedValue, 64)
+	match, err := ctx.valueMatches(actualValue, expectedValue)
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
-			return err
-		}
-	case reflect.Int32:
-		expectedParsedValue, err = strconv.ParseInt(expectedValue, 10, 64)
+	if !match {
+		return fmt.Errorf("expected json path to have value %v but it is %v", expectedValue, stringifyJSON(actualValue))
+	}
 
-		if err != nil {
-			return err
-		}
-	case reflect.Int64:
-		expectedParsedValue, err = strconv.ParseInt(expectedValue, 10, 64)
+	return nil
+}
+
+// valueMatches Checks if a decoded JSON value matches the expected value of a step.
+// Strings are compared as text, booleans are parsed, and the other values are compared with the expected value parsed as JSON.
+func (ctx *ApiContext) valueMatches(actualValue interface{}, expectedValue string) (bool, error) {
+	switch v := actualValue.(type) {
+	case string:
+		return v == expectedValue, nil
+	case bool:
+		expectedParsedValue, err := strconv.ParseBool(expectedValue)
 
 		if err != nil {
-			return err
+			return false, err
 		}
 
+		return v == expectedParsedValue, nil
 	default:
-		expectedParsedValue = expectedValue
+		expectedParsedValue, err := decodeJSON(expectedValue)
+		return err == nil && ctx.valuesEqual(actualValue, expectedParsedValue), nil
 	}
-
-	if actualValue != expectedParsedValue {
-		return fmt.Errorf("expected json path to have value %v but it is %v", expectedParsedValue, actualValue)
-	}
-
-	return nil
 }
 
 // TheJSONPathShouldMatch Validates Checks if the the value from the specified json path matches the specified pattern.
 func (ctx *ApiContext) TheJSONPathShouldMatch(pathExpr string, pattern string) error {
-	var jsonData interface{}
 	var match bool
 
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
-		return err
-	}
-
-	value, err := jsonpath.Get(pathExpr, jsonData)
+	value, err := ctx.JSONPath(pathExpr)
 
 	if err != nil {
 		return err
@@ -420,20 +703,14 @@ func (ctx *ApiContext) TheJSONPathShouldMatch(pathExpr string, pattern string) e
 
 // TheJSONPathShouldBePresent checks if the specified json path exists in the response body
 func (ctx *ApiContext) TheJSONPathShouldBePresent(pathExpr string) error {
-	var jsonData interface{}
-
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
-		return err
-	}
-
-	value, err := jsonpath.Get(pathExpr, jsonData)
+	value, err := ctx.JSONPath(pathExpr)
 
 	if err != nil {
 		return err
 	}
 
 	if value == nil {
-		return fmt.Errorf("the json path %s was not present in the response", pathExpr)
+		return fmt.Errorf("%w: %s was not present in the response", ErrPathNotFound, pathExpr)
 	}
 
 	return nil
@@ -441,14 +718,7 @@ func (ctx *ApiContext) TheJSONPathShouldBePresent(pathExpr string) error {
 
 // TheJSONPathHaveCount Validates if the field at the specified json path have the expected length
 func (ctx *ApiContext) TheJSONPathHaveCount(pathExpr string, expectedCount int) error {
-	var jsonData interface{}
-
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
-		return err
-
-	}
-
-	value, err := jsonpath.Get(pathExpr, jsonData)
+	value, err := ctx.JSONPath(pathExpr)
 
 	if err != nil {
 		return err
@@ -468,26 +738,49 @@ func (ctx *ApiContext) TheJSONPathHaveCount(pathExpr string, expectedCount int)
 }
 
 // TheResponseShouldMatchJSON Check that response matches the expected JSON.
+// Numbers are compared by value, with the configured number tolerance.
 func (ctx *ApiContext) TheResponseShouldMatchJSON(body *godog.DocString) error {
-	actual := strings.Trim(ctx.lastResponse.Body, "\n")
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
 
-	expected := ctx.ReplaceScopeVariables(body.Content)
+	actualValue, err := response.JSON()
+	if err != nil {
+		return err
+	}
 
-	match, err := isEqualJson(actual, expected)
+	expected, err := ctx.replaceScopeVariablesInJSON(body.Content)
 	if err != nil {
 		return err
 	}
-	if !match {
-		return fmt.Errorf("expected json %s, does not match actual: %s", expected, actual)
+
+	expectedValue, err := decodeJSON(expected)
+	if err != nil {
+		return fmt.Errorf("invalid expected json: %v", err)
+	}
+
+	if !ctx.valuesEqual(actualValue, expectedValue) {
+		return fmt.Errorf("expected json %s, does not match actual: %s", expected, strings.Trim(response.Body, "\n"))
 	}
 	return nil
 }
 
 // TheResponseBodyShouldContain Checks if the response body contains the specified string
 func (ctx *ApiContext) TheResponseBodyShouldContain(s string) error {
-	bodyContent := strings.Trim(ctx.lastResponse.Body, "\n")
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	bodyContent := strings.Trim(response.Body, "\n")
+
+	s, err = ctx.replaceScopeVariables(s)
+	if err != nil {
+		return err
+	}
 
-	if !strings.Contains(bodyContent, ctx.ReplaceScopeVariables(s)) {
+	if !strings.Contains(bodyContent, s) {
 		return fmt.Errorf("%s does not contain %s", bodyContent, s)
 	}
 	return nil
@@ -495,8 +788,12 @@ func (ctx *ApiContext) TheResponseBodyShouldContain(s string) error {
 
 // TheResponseBodyMatch Checks if the response body matches the specified pattern
 func (ctx *ApiContext) TheResponseBodyShouldMatch(pattern string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
 
-	bodyContents := ctx.lastResponse.Body
+	bodyContents := response.Body
 	match, err := regexp.MatchString(pattern, bodyContents)
 
 	if err != nil {
@@ -512,6 +809,10 @@ func (ctx *ApiContext) TheResponseBodyShouldMatch(pattern string) error {
 
 // TheResponseShouldMatchJsonSchema Checks if the response matches the specified JSON schema
 func (ctx *ApiContext) TheResponseShouldMatchJsonSchema(path string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
 
 	path = strings.Trim(path, "/")
 
@@ -527,7 +828,7 @@ func (ctx *ApiContext) TheResponseShouldMatchJsonSchema(path string) error {
 	}
 
 	schemaLoader := gojsonschema.NewBytesLoader(schemaContents)
-	documentLoader := gojsonschema.NewStringLoader(ctx.lastResponse.Body)
+	documentLoader := gojsonschema.NewStringLoader(response.Body)
 	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
 
 	if err != nil {
@@ -540,7 +841,7 @@ func (ctx *ApiContext) TheResponseShouldMatchJsonSchema(path string) error {
 			schemaErrors = append(schemaErrors, error.String())
 		}
 
-		return fmt.Errorf("the response is not valid according to the specified schema %s\n %v", path, schemaErrors)
+		return fmt.Errorf("%w %s\n %v", ErrSchemaMismatch, path, schemaErrors)
 	}
 
 	return nil
@@ -548,15 +849,129 @@ func (ctx *ApiContext) TheResponseShouldMatchJsonSchema(path string) error {
 
 // TheResponseHeaderShouldHaveValue Verify the value of a response header
 func (ctx *ApiContext) TheResponseHeaderShouldHaveValue(name string, expectedValue string) error {
-	actualValue := ctx.lastResponse.ResponseObj.Header.Get(name)
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
 
-	if actualValue != ctx.ReplaceScopeVariables(expectedValue) {
+	actualValue := response.ResponseObj.Header.Get(name)
+
+	expectedValue, err = ctx.replaceScopeVariables(expectedValue)
+	if err != nil {
+		return err
+	}
+
+	if actualValue != expectedValue {
 		return fmt.Errorf("expected header to have value %s. actual : %s", expectedValue, actualValue)
 	}
 
 	return nil
 }
 
+// TheResponseHeaderShouldContain Verify that one of the values of a response header is the expected value.
+// All the values of the header are checked, including comma separated lists like "Vary: Accept, Origin".
+func (ctx *ApiContext) TheResponseHeaderShouldContain(name string, expectedValue string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	expectedValue, err = ctx.replaceScopeVariables(expectedValue)
+	if err != nil {
+		return err
+	}
+
+	values := response.ResponseObj.Header.Values(name)
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if strings.TrimSpace(item) == expectedValue {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("expected header %s to contain %s. actual values: %v", name, expectedValue, values)
+}
+
+// TheResponseHeaderShouldMatch Verify that the value of a response header matches the specified pattern.
+func (ctx *ApiContext) TheResponseHeaderShouldMatch(name string, pattern string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	pattern, err = ctx.replaceScopeVariables(pattern)
+	if err != nil {
+		return err
+	}
+
+	actualValue := response.ResponseObj.Header.Get(name)
+	match, err := regexp.MatchString(pattern, actualValue)
+	if err != nil {
+		return err
+	}
+
+	if !match {
+		return fmt.Errorf("expected header %s to match %s. actual : %s", name, pattern, actualValue)
+	}
+
+	return nil
+}
+
+// TheResponseHeaderShouldBePresent Verify that the response has the specified header.
+func (ctx *ApiContext) TheResponseHeaderShouldBePresent(name string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := response.ResponseObj.Header[http.CanonicalHeaderKey(name)]; !ok {
+		return fmt.Errorf("expected header %s to be present in the response", name)
+	}
+
+	return nil
+}
+
+// TheResponseHeaderShouldNotBePresent Verify that the response does not have the specified header.
+func (ctx *ApiContext) TheResponseHeaderShouldNotBePresent(name string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	if values, ok := response.ResponseObj.Header[http.CanonicalHeaderKey(name)]; ok {
+		return fmt.Errorf("expected header %s not to be present in the response. actual values: %v", name, values)
+	}
+
+	return nil
+}
+
+// TheResponseContentTypeShouldBe Verify the media type of the response, ignoring parameters like charset.
+// Ex: "application/json" matches "application/json; charset=utf-8".
+func (ctx *ApiContext) TheResponseContentTypeShouldBe(expectedType string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	expectedType, err = ctx.replaceScopeVariables(expectedType)
+	if err != nil {
+		return err
+	}
+
+	contentType := response.ResponseObj.Header.Get("Content-Type")
+	actualType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid response content type %q: %v", contentType, err)
+	}
+
+	if !strings.EqualFold(actualType, strings.TrimSpace(expectedType)) {
+		return fmt.Errorf("expected content type to be %s. actual : %s", expectedType, actualType)
+	}
+
+	return nil
+}
+
 // logRequest Helper function to log the request
 func (ctx *ApiContext) logRequest(request *http.Request) {
 	if !ctx.debug {
@@ -584,57 +999,112 @@ func (ctx *ApiContext) WaitForSomeTime(timeToWait int) error {
 	return nil
 }
 
-// StoreScopeData Store data in scope map.
+// SetScopeVariable Stores a variable in the specified scope level.
+// The value keeps its JSON type (string, number, bool, object or array).
+// It can be used from TestSuiteInitializer hooks to seed the global scope.
+func (ctx *ApiContext) SetScopeVariable(level ScopeLevel, key string, value interface{}) error {
+	return ctx.scope.Set(level, key, value)
+}
+
+// StoreScopeData Store data in scenario scope.
 func (ctx *ApiContext) StoreScopeData(scopeKeyName string, value string) error {
-	ctx.scope[scopeKeyName] = value
-	return nil
+	return ctx.StoreScopeDataIn(string(ScenarioScope), scopeKeyName, value)
 }
 
-// StoreResponseHeader Store header value to scope map.
-func (ctx *ApiContext) StoreResponseHeader(name string, scopeKeyName string) error {
-	actualValue := ctx.lastResponse.ResponseObj.Header.Get(name)
-	ctx.scope[scopeKeyName] = actualValue
-	return nil
+// StoreScopeDataIn Store data in the specified scope level.
+func (ctx *ApiContext) StoreScopeDataIn(level string, scopeKeyName string, value string) error {
+	return ctx.scope.Set(ScopeLevel(level), scopeKeyName, value)
 }
 
-// StoreJsonPathValue Store value from json body path to scope map.
-func (ctx *ApiContext) StoreJsonPathValue(pathExpr string, scopeKeyName string) error {
-	var jsonData interface{}
+// StoreResponseHeader Store header value to scenario scope.
+func (ctx *ApiContext) StoreResponseHeader(name string, scopeKeyName string) error {
+	return ctx.StoreResponseHeaderIn(name, scopeKeyName, string(ScenarioScope))
+}
 
-	if err := json.Unmarshal([]byte(ctx.lastResponse.Body), &jsonData); err != nil {
+// StoreResponseHeaderIn Store header value to the specified scope level.
+func (ctx *ApiContext) StoreResponseHeaderIn(name string, scopeKeyName string, level string) error {
+	response, err := ctx.response()
+	if err != nil {
 		return err
 	}
 
-	actualValue, err := jsonpath.Get(pathExpr, jsonData)
+	actualValue := response.ResponseObj.Header.Get(name)
+	return ctx.scope.Set(ScopeLevel(level), scopeKeyName, actualValue)
+}
+
+// StoreJsonPathValue Store value from json body path to scenario scope.
+func (ctx *ApiContext) StoreJsonPathValue(pathExpr string, scopeKeyName string) error {
+	return ctx.StoreJsonPathValueIn(pathExpr, scopeKeyName, string(ScenarioScope))
+}
+
+// StoreJsonPathValueIn Store value from json body path to the specified scope level.
+func (ctx *ApiContext) StoreJsonPathValueIn(pathExpr string, scopeKeyName string, level string) error {
+	actualValue, err := ctx.JSONPath(pathExpr)
 
 	if err != nil {
 		return err
 	}
-	switch v := actualValue.(type) {
-	case string:
-		ctx.scope[scopeKeyName] = v
-	default:
-		ctx.scope[scopeKeyName] = fmt.Sprint(v)
+
+	return ctx.scope.Set(ScopeLevel(level), scopeKeyName, actualValue)
+}
+
+// TheScopeVariableShouldHaveValue Verify the value of a scope variable.
+// String variables are compared as text, while other types are compared with the expected value parsed as JSON.
+func (ctx *ApiContext) TheScopeVariableShouldHaveValue(scopeKeyName string, expectedValue string) error {
+	actualValue, ok := ctx.scope.Get(scopeKeyName)
+	if !ok {
+		actualValue = ""
+	}
+
+	if _, isString := actualValue.(string); !isString {
+		if expectedParsedValue, err := decodeJSON(expectedValue); err == nil && ctx.valuesEqual(actualValue, expectedParsedValue) {
+			return nil
+		}
 	}
+
+	if stringifyJSON(actualValue) != expectedValue {
+		return fmt.Errorf("expected scope variable to have value %s. actual : %s", expectedValue, stringifyJSON(actualValue))
+	}
+
 	return nil
 }
 
-// TheScopeVariableShouldHaveValue Verify the value of a scope variable
-func (ctx *ApiContext) TheScopeVariableShouldHaveValue(scopeKeyName string, expectedValue string) error {
-	if ctx.scope[scopeKeyName] != expectedValue {
-		return fmt.Errorf("expected scope variable to have value %s. actual : %s", expectedValue, ctx.scope[scopeKeyName])
+// TheScopeVariableShouldMatchJSON Verify that a scope variable is structurally equal to the expected JSON.
+func (ctx *ApiContext) TheScopeVariableShouldMatchJSON(scopeKeyName string, body *godog.DocString) error {
+	actualValue, ok := ctx.scope.Get(scopeKeyName)
+	if !ok {
+		return fmt.Errorf("scope variable %q is not defined", scopeKeyName)
+	}
+
+	expected, err := ctx.replaceScopeVariablesInJSON(body.Content)
+	if err != nil {
+		return err
+	}
+
+	expectedValue, err := decodeJSON(expected)
+	if err != nil {
+		return err
+	}
+
+	if str, isString := actualValue.(string); isString {
+		if decoded, err := decodeJSON(str); err == nil {
+			actualValue = decoded
+		}
+	}
+
+	if !ctx.valuesEqual(actualValue, expectedValue) {
+		return fmt.Errorf("expected scope variable %s to match json %s, but it is %s", scopeKeyName, expected, jsonLiteral(actualValue))
 	}
 
 	return nil
 }
 
+// ReplaceScopeVariables Replaces every "`##key`" placeholder in data with the value of the matching scope variable.
+// Variables that cannot be resolved are replaced with an empty string. Strict mode is ignored here.
+//
+// Deprecated: use ExpandScopeVariables, which returns an error for undefined variables in strict mode.
 func (ctx *ApiContext) ReplaceScopeVariables(data string) string {
-	re := regexp.MustCompile("`##(.*?)`")
-	matches := re.FindStringSubmatch(data)
-	if len(matches) < 1 || len(matches[1]) < 1 {
-		return data
-	}
-	dataToReplace := matches[0]
-	scopeKey := matches[1]
-	return strings.Replace(data, dataToReplace, ctx.scope[scopeKey], 1)
+	replaced, _ := ctx.expandScopePlaceholders(data, false, false)
+
+	return replaced
 }
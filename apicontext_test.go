@@ -6,12 +6,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cucumber/godog"
-	"github.com/cucumber/messages-go/v10"
+	messages "github.com/cucumber/messages/go/v21"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,9 +37,9 @@ func TestApiContext_ISetHeadersTo(t *testing.T) {
 	ctx := setupTestContext()
 
 	dt := &godog.Table{
-		Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+		Rows: []*messages.PickleTableRow{
 			{
-				Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{
+				Cells: []*messages.PickleTableCell{
 					{
 						Value: "X-Header-1",
 					},
@@ -47,7 +49,7 @@ func TestApiContext_ISetHeadersTo(t *testing.T) {
 				},
 			},
 			{
-				Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{
+				Cells: []*messages.PickleTableCell{
 					{
 						Value: "X-Header-2",
 					},
@@ -62,8 +64,8 @@ func TestApiContext_ISetHeadersTo(t *testing.T) {
 	err := ctx.ISetHeadersTo(dt)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "value 1", ctx.headers["X-Header-1"])
-	assert.Equal(t, "value 2", ctx.headers["X-Header-2"])
+	assert.Equal(t, "value 1", ctx.headers.Get("X-Header-1"))
+	assert.Equal(t, "value 2", ctx.headers.Get("X-Header-2"))
 }
 
 func TestApiContext_ISetHeaderWithValue(t *testing.T) {
@@ -72,7 +74,7 @@ func TestApiContext_ISetHeaderWithValue(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(ctx.headers))
-	assert.Equal(t, "application/json", ctx.headers["Content-Type"])
+	assert.Equal(t, "application/json", ctx.headers.Get("Content-Type"))
 }
 
 func TestApiContext_ISetQueryParamWithValue(t *testing.T) {
@@ -81,16 +83,16 @@ func TestApiContext_ISetQueryParamWithValue(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(ctx.queryParams))
-	assert.Equal(t, "1", ctx.queryParams["page"])
+	assert.Equal(t, "1", ctx.queryParams.Get("page"))
 }
 
 func TestApiContext_ISetQueryParamsTo(t *testing.T) {
 	ctx := setupTestContext()
 
 	dt := &godog.Table{
-		Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+		Rows: []*messages.PickleTableRow{
 			{
-				Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{
+				Cells: []*messages.PickleTableCell{
 					{
 						Value: "q1",
 					},
@@ -100,7 +102,7 @@ func TestApiContext_ISetQueryParamsTo(t *testing.T) {
 				},
 			},
 			{
-				Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{
+				Cells: []*messages.PickleTableCell{
 					{
 						Value: "q2",
 					},
@@ -115,8 +117,8 @@ func TestApiContext_ISetQueryParamsTo(t *testing.T) {
 	err := ctx.ISetQueryParamsTo(dt)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "v1", ctx.queryParams["q1"])
-	assert.Equal(t, "v2", ctx.queryParams["q2"])
+	assert.Equal(t, "v1", ctx.queryParams.Get("q1"))
+	assert.Equal(t, "v2", ctx.queryParams.Get("q2"))
 }
 
 func TestApiContext_ISendRequestTo(t *testing.T) {
@@ -154,6 +156,56 @@ func TestApiContext_ISendRequestTo(t *testing.T) {
 	}))
 }
 
+func TestApiContext_MultiValueHeadersAndQueryParams(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Tags", strings.Join(r.URL.Query()["tag"], ","))
+		w.Header().Set("X-Accept", strings.Join(r.Header.Values("Accept"), ","))
+		w.Header().Add("Vary", "Accept, Origin")
+		w.Header().Add("Vary", "Accept-Encoding")
+	}))
+
+	defer ts.Close()
+
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL)
+
+	dt := &godog.Table{
+		Rows: []*messages.PickleTableRow{
+			{
+				Cells: []*messages.PickleTableCell{
+					{
+						Value: "Accept",
+					},
+					{
+						Value: "application/json",
+					},
+				},
+			},
+			{
+				Cells: []*messages.PickleTableCell{
+					{
+						Value: "Accept",
+					},
+					{
+						Value: "text/plain",
+					},
+				},
+			},
+		},
+	}
+
+	assert.Nil(t, ctx.ISetHeadersTo(dt))
+	assert.Nil(t, ctx.ISetQueryParamWithValue("tag", "a"))
+	assert.Nil(t, ctx.IAddQueryParamWithValue("tag", "b"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValue("X-Tags", "a,b"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValue("X-Accept", "application/json,text/plain"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldContain("Vary", "Origin"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldContain("Vary", "Accept-Encoding"))
+	assert.Error(t, ctx.TheResponseHeaderShouldContain("Vary", "Cookie"))
+}
+
 func TestApiContext_ISendRequestToWithFormBody(t *testing.T) {
 	value := "world"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -177,9 +229,9 @@ func TestApiContext_ISendRequestToWithFormBody(t *testing.T) {
 		WithBaseURL(ts.URL)
 
 	dt := &godog.Table{
-		Rows: []*messages.PickleStepArgument_PickleTable_PickleTableRow{
+		Rows: []*messages.PickleTableRow{
 			{
-				Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{
+				Cells: []*messages.PickleTableCell{
 					{
 						Value: "hello",
 					},
@@ -192,7 +244,7 @@ func TestApiContext_ISendRequestToWithFormBody(t *testing.T) {
 				},
 			},
 			{
-				Cells: []*messages.PickleStepArgument_PickleTable_PickleTableRow_PickleTableCell{
+				Cells: []*messages.PickleTableCell{
 					{
 						Value: "simplefile",
 					},
@@ -265,6 +317,29 @@ func TestApiContext_TheResponseHeaderShouldHaveValue(t *testing.T) {
 	assert.NotNil(t, ctx.TheResponseHeaderShouldHaveValue("non-existing-header", "hello"))
 }
 
+func TestApiContext_ResponseHeaderAssertions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Request-Id", "req-1234")
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL)
+
+	assert.Nil(t, ctx.StoreScopeData("prefix", "req"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+
+	assert.Nil(t, ctx.TheResponseHeaderShouldMatch("X-Request-Id", "^`##prefix`-[0-9]+$"))
+	assert.Error(t, ctx.TheResponseHeaderShouldMatch("X-Request-Id", "^[0-9]+$"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldBePresent("x-request-id"))
+	assert.Error(t, ctx.TheResponseHeaderShouldBePresent("X-Missing"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldNotBePresent("X-Missing"))
+	assert.Error(t, ctx.TheResponseHeaderShouldNotBePresent("X-Request-Id"))
+	assert.Nil(t, ctx.TheResponseContentTypeShouldBe("application/json"))
+	assert.Error(t, ctx.TheResponseContentTypeShouldBe("text/html"))
+}
+
 func TestApiContext_TheResponseShouldMatchJsonSchema(t *testing.T) {
 	p := make(map[string]interface{})
 	p["firstName"] = "Bruno"
@@ -474,15 +549,32 @@ func TestApiContext_TheResponseShouldMatchJSON(t *testing.T) {
 	)
 }
 
+func TestApiContext_TheResponseShouldMatchJSONNumbers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 12345678901234567891, "price": 10.004}`))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"price": 10.0040, "id": 12345678901234567891}`}))
+	assert.Error(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"id": 12345678901234567890, "price": 10.004}`}))
+	assert.Error(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"id": 12345678901234567891, "price": 10}`}))
+	assert.Nil(t, ctx.WithNumberTolerance(0.01).TheResponseShouldMatchJSON(&godog.DocString{Content: `{"id": 12345678901234567891, "price": 10}`}))
+	assert.Error(t, ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: `{"id": `}))
+}
+
 func TestReset(t *testing.T) {
 	ctx := setupTestContext()
 
 	p := &messages.Pickle{}
-	ctx.headers = map[string]string{
-		"Content-Type": "application/json",
+	ctx.headers = http.Header{
+		"Content-Type": {"application/json"},
 	}
-	ctx.queryParams = map[string]string{
-		"param": "test",
+	ctx.queryParams = url.Values{
+		"param": {"test"},
 	}
 
 	ctx.reset(p)
@@ -491,6 +583,47 @@ func TestReset(t *testing.T) {
 	assert.Empty(t, ctx.queryParams)
 }
 
+func TestReset_DefaultAndPersistentHeaders(t *testing.T) {
+	ctx := setupTestContext().
+		WithDefaultHeaders(map[string]string{"Accept": "application/json"}).
+		WithDefaultQueryParams(map[string]string{"lang": "en"})
+
+	ctx.reset(&messages.Pickle{Uri: "features/a.feature"})
+	assert.Nil(t, ctx.ISetPersistentHeaderWithValue("X-Tenant", "acme"))
+	assert.Nil(t, ctx.ISetPersistentQueryParamWithValue("page", "1"))
+	assert.Nil(t, ctx.ISetHeaderWithValue("X-Request", "1"))
+	assert.Nil(t, ctx.IRemoveHeader("accept"))
+	assert.Nil(t, ctx.IRemoveQueryParam("lang"))
+	assert.Equal(t, http.Header{"X-Tenant": {"acme"}, "X-Request": {"1"}}, ctx.headers)
+	assert.Equal(t, url.Values{"page": {"1"}}, ctx.queryParams)
+
+	ctx.reset(&messages.Pickle{Uri: "features/a.feature"})
+	assert.Equal(t, http.Header{"Accept": {"application/json"}, "X-Tenant": {"acme"}}, ctx.headers)
+	assert.Equal(t, url.Values{"lang": {"en"}, "page": {"1"}}, ctx.queryParams)
+
+	ctx.reset(&messages.Pickle{Uri: "features/b.feature"})
+	assert.Equal(t, http.Header{"Accept": {"application/json"}}, ctx.headers)
+	assert.Equal(t, url.Values{"lang": {"en"}}, ctx.queryParams)
+}
+
+func TestReset_Scope(t *testing.T) {
+	ctx := setupTestContext()
+
+	ctx.reset(&messages.Pickle{Uri: "features/a.feature"})
+	assert.Nil(t, ctx.SetScopeVariable(GlobalScope, "global", "g"))
+	assert.Nil(t, ctx.StoreScopeDataIn("feature", "feature", "f"))
+	assert.Nil(t, ctx.StoreScopeData("scenario", "s"))
+
+	ctx.reset(&messages.Pickle{Uri: "features/a.feature"})
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("global", "g"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("feature", "f"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("scenario", ""))
+
+	ctx.reset(&messages.Pickle{Uri: "features/b.feature"})
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("global", "g"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("feature", ""))
+}
+
 func TestApiContext_WaitForSomeTime(t *testing.T) {
 	ctx := setupTestContext()
 	currentTime := time.Now()
@@ -561,4 +694,14 @@ func TestApiContext_ReplaceScopeVariables(t *testing.T) {
 	newData := ctx.ReplaceScopeVariables("hello `##hello` good")
 	assert.Nil(t, err)
 	assert.Equal(t, newData, "hello world good")
+
+	ctx.WithStrictScope(true)
+	assert.Equal(t, "hello ", ctx.ReplaceScopeVariables("hello `##missing`"))
+
+	_, err = ctx.ExpandScopeVariables("hello `##missing`")
+	assert.EqualError(t, err, `scope variable "missing" is not defined`)
+
+	expanded, err := ctx.ExpandScopeVariablesInJSON("{\"hello\": `##hello`}")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"hello": "world"}`, expanded)
 }
@@ -0,0 +1,168 @@
+package apicontext
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// Register the image formats decoded by the image steps.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// TheResponseBodyShouldHaveSHA256 Checks the SHA-256 checksum of the response body, in hexadecimal.
+func (ctx *ApiContext) TheResponseBodyShouldHaveSHA256(expectedChecksum string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256([]byte(response.Body))
+	actualChecksum := hex.EncodeToString(sum[:])
+
+	if !strings.EqualFold(actualChecksum, strings.TrimSpace(expectedChecksum)) {
+		return fmt.Errorf("expected response body to have sha256 %s, but actual is %s", expectedChecksum, actualChecksum)
+	}
+
+	return nil
+}
+
+// TheResponseSizeShouldBe Checks the size in bytes of the response body.
+func (ctx *ApiContext) TheResponseSizeShouldBe(expectedSize int) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	if len(response.Body) != expectedSize {
+		return fmt.Errorf("expected response size to be %d bytes, but actual is %d bytes", expectedSize, len(response.Body))
+	}
+
+	return nil
+}
+
+// TheResponseBodyShouldEqualFile Compares the response body with the contents of a file, byte by byte.
+// Relative paths are resolved from the fixtures path.
+func (ctx *ApiContext) TheResponseBodyShouldEqualFile(path string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	path, err = ctx.fixturePath(path)
+	if err != nil {
+		return err
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read expected file: %w", err)
+	}
+
+	actual := []byte(response.Body)
+	if !bytes.Equal(actual, expected) {
+		return fmt.Errorf("expected response body to equal file %s (%d bytes), but it differs from byte %d (%d bytes)", path, len(expected), firstDifference(actual, expected), len(actual))
+	}
+
+	return nil
+}
+
+// TheResponseImageShouldHaveDimensions Checks the width and height of a PNG, JPEG or GIF response body.
+func (ctx *ApiContext) TheResponseImageShouldHaveDimensions(width int, height int) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	config, format, err := image.DecodeConfig(strings.NewReader(response.Body))
+	if err != nil {
+		return fmt.Errorf("the response body is not a supported image: %v", err)
+	}
+
+	if config.Width != width || config.Height != height {
+		return fmt.Errorf("expected %s image to have dimensions %dx%d, but actual is %dx%d", format, width, height, config.Width, config.Height)
+	}
+
+	return nil
+}
+
+// TheResponseBodyShouldBeDetectedAs Checks the media type detected from the first bytes of the response body, ignoring the Content-Type header.
+// The detection follows https://mimesniff.spec.whatwg.org, ex: application/pdf, image/png or application/zip.
+func (ctx *ApiContext) TheResponseBodyShouldBeDetectedAs(expectedType string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	actualType, _, err := mime.ParseMediaType(http.DetectContentType([]byte(response.Body)))
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(actualType, strings.TrimSpace(expectedType)) {
+		return fmt.Errorf("expected response body to be detected as %s, but actual is %s", expectedType, actualType)
+	}
+
+	return nil
+}
+
+// ISaveTheResponseBodyTo Writes the response body to a file, creating its directory if needed.
+func (ctx *ApiContext) ISaveTheResponseBodyTo(path string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	path, err = ctx.replaceScopeVariables(path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot save the response body: %w", err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(response.Body), 0644); err != nil {
+		return fmt.Errorf("cannot save the response body: %w", err)
+	}
+
+	return nil
+}
+
+// fixturePath Resolves a file path from the fixtures path, after replacing the scope placeholders.
+func (ctx *ApiContext) fixturePath(path string) (string, error) {
+	path, err := ctx.replaceScopeVariables(path)
+	if err != nil {
+		return "", err
+	}
+
+	if ctx.fixturesPath != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(ctx.fixturesPath, path)
+	}
+
+	return path, nil
+}
+
+// firstDifference Returns the offset of the first byte that differs between two byte slices.
+func firstDifference(a []byte, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+
+	if len(a) < len(b) {
+		return len(a)
+	}
+
+	return len(b)
+}
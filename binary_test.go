@@ -0,0 +1,72 @@
+package apicontext
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_BinaryResponse(t *testing.T) {
+	var img bytes.Buffer
+	assert.Nil(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 64, 32))))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logo.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(img.Bytes())
+		case "/report.pdf":
+			_, _ = w.Write([]byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"))
+		}
+	}))
+
+	defer ts.Close()
+	dir := t.TempDir()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithFixturesPath(dir).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/logo.png"))
+
+	sum := sha256.Sum256(img.Bytes())
+	assert.Nil(t, ctx.TheResponseBodyShouldHaveSHA256(hex.EncodeToString(sum[:])))
+	assert.Error(t, ctx.TheResponseBodyShouldHaveSHA256("0000"))
+
+	assert.Nil(t, ctx.TheResponseSizeShouldBe(img.Len()))
+	assert.Error(t, ctx.TheResponseSizeShouldBe(img.Len()+1))
+
+	assert.Nil(t, ctx.TheResponseImageShouldHaveDimensions(64, 32))
+	assert.EqualError(t, ctx.TheResponseImageShouldHaveDimensions(32, 32), "expected png image to have dimensions 32x32, but actual is 64x32")
+	assert.Nil(t, ctx.TheResponseBodyShouldBeDetectedAs("image/png"))
+
+	assert.Nil(t, ctx.ISaveTheResponseBodyTo(filepath.Join(dir, "downloads", "logo.png")))
+	saved, err := ioutil.ReadFile(filepath.Join(dir, "downloads", "logo.png"))
+	assert.Nil(t, err)
+	assert.Equal(t, img.Bytes(), saved)
+
+	assert.Nil(t, ctx.TheResponseBodyShouldEqualFile("downloads/logo.png"))
+	assert.Error(t, ctx.TheResponseBodyShouldEqualFile("missing.png"))
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/report.pdf"))
+	assert.Nil(t, ctx.TheResponseBodyShouldBeDetectedAs("application/pdf"))
+	assert.Error(t, ctx.TheResponseImageShouldHaveDimensions(64, 32))
+	assert.EqualError(t, ctx.TheResponseBodyShouldEqualFile("downloads/logo.png"),
+		fmt.Sprintf("expected response body to equal file %s (%d bytes), but it differs from byte 0 (15 bytes)", filepath.Join(dir, "downloads", "logo.png"), img.Len()))
+}
+
+func TestFirstDifference(t *testing.T) {
+	assert.Equal(t, 2, firstDifference([]byte("abc"), []byte("abd")))
+	assert.Equal(t, 2, firstDifference([]byte("ab"), []byte("abc")))
+	assert.Equal(t, 3, firstDifference([]byte("abc"), []byte("abc")))
+}
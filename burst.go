@@ -0,0 +1,241 @@
+package apicontext
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// burstResult A request sent in a concurrent burst, with its response and response time,
+// or the error that prevented receiving a response.
+type burstResult struct {
+	request  *http.Request
+	response *ApiResponse
+	duration time.Duration
+	finished time.Time
+	err      error
+}
+
+// ISendConcurrentRequestsTo Sends the same request several times concurrently, ex: to check race conditions.
+func (ctx *ApiContext) ISendConcurrentRequestsTo(count int, method, uri string) error {
+	return ctx.sendBurst(count, method, uri, nil)
+}
+
+// ISendConcurrentRequestsToWithBody Sends the same request with json body several times concurrently,
+// ex: to check that requests with the same idempotency key create a single resource.
+func (ctx *ApiContext) ISendConcurrentRequestsToWithBody(count int, method, uri string, requestBody *godog.DocString) error {
+	body, err := ctx.replaceScopeVariablesInJSON(requestBody.Content)
+	if err != nil {
+		return err
+	}
+
+	return ctx.sendBurst(count, method, uri, []byte(body))
+}
+
+// AllResponsesShouldHaveStatus Checks that every response of the last concurrent burst has the specified status code.
+// Requests that failed without a response do not match any status code.
+func (ctx *ApiContext) AllResponsesShouldHaveStatus(statusCode int) error {
+	if len(ctx.burstResults) == 0 {
+		return fmt.Errorf("%w: no concurrent requests were sent in this scenario", ErrNoResponse)
+	}
+
+	if count := ctx.countBurstStatus(statusCode); count != len(ctx.burstResults) {
+		return fmt.Errorf("expected all %d responses to have status %d, but got: %s", len(ctx.burstResults), statusCode, ctx.burstStatusSummary())
+	}
+
+	return nil
+}
+
+// ExactlyResponsesShouldHaveStatus Checks the number of responses of the last concurrent burst with the specified status code.
+// Requests that failed without a response do not match any status code.
+func (ctx *ApiContext) ExactlyResponsesShouldHaveStatus(expectedCount int, statusCode int) error {
+	if len(ctx.burstResults) == 0 {
+		return fmt.Errorf("%w: no concurrent requests were sent in this scenario", ErrNoResponse)
+	}
+
+	if count := ctx.countBurstStatus(statusCode); count != expectedCount {
+		return fmt.Errorf("expected exactly %d response(s) to have status %d, but %d have it. Statuses: %s", expectedCount, statusCode, count, ctx.burstStatusSummary())
+	}
+
+	return nil
+}
+
+// PercentileResponseTimeShouldBeBelow Checks a percentile of the response times of the last concurrent burst, ex: p95.
+// The percentile is computed with the nearest-rank method, on the requests that received a response.
+func (ctx *ApiContext) PercentileResponseTimeShouldBeBelow(percentile int, milliseconds int) error {
+	if len(ctx.burstResults) == 0 {
+		return fmt.Errorf("%w: no concurrent requests were sent in this scenario", ErrNoResponse)
+	}
+
+	if percentile < 1 || percentile > 100 {
+		return fmt.Errorf("invalid percentile p%d, expected a value between 1 and 100", percentile)
+	}
+
+	var durations []time.Duration
+	for _, result := range ctx.burstResults {
+		if result.err == nil {
+			durations = append(durations, result.duration)
+		}
+	}
+
+	if len(durations) == 0 {
+		return fmt.Errorf("%w: none of the %d concurrent requests received a response. Statuses: %s", ErrNoResponse, len(ctx.burstResults), ctx.burstStatusSummary())
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	rank := int(math.Ceil(float64(percentile) / 100 * float64(len(durations))))
+	actual := durations[rank-1]
+	limit := time.Duration(milliseconds) * time.Millisecond
+
+	if actual >= limit {
+		return fmt.Errorf("expected p%d response time to be below %v, but actual is %v (min %v, max %v)", percentile, limit, actual.Round(time.Millisecond),
+			durations[0].Round(time.Millisecond), durations[len(durations)-1].Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// sendBurst Sends the same request concurrently, and keeps all the responses.
+// The requests are built beforehand and released together, to maximize their overlap.
+// Requests that fail without a response, ex: because the connection was reset under load, are kept as results
+// for the aggregate assertions instead of failing the step. The response that finished last becomes the last response.
+func (ctx *ApiContext) sendBurst(count int, method, uri string, body []byte) error {
+	if count < 1 {
+		return fmt.Errorf("invalid number of concurrent requests %d", count)
+	}
+
+	ctx.burstResults = make([]burstResult, count)
+	ctx.lastResponse = nil
+
+	for i := range ctx.burstResults {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+
+		req, err := ctx.newRequest(method, uri, reader)
+		if err != nil {
+			return err
+		}
+
+		acceptGzip(req)
+		ctx.logRequest(req)
+		ctx.burstResults[i].request = req
+	}
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for i := range ctx.burstResults {
+		wg.Add(1)
+
+		go func(result *burstResult) {
+			defer wg.Done()
+			<-start
+
+			begin := time.Now()
+			result.response, result.err = ctx.doBurstRequest(result.request)
+			result.finished = time.Now()
+			result.duration = result.finished.Sub(begin)
+		}(&ctx.burstResults[i])
+	}
+
+	close(start)
+	wg.Wait()
+
+	var last *burstResult
+	for i := range ctx.burstResults {
+		result := &ctx.burstResults[i]
+		if result.err != nil {
+			if ctx.debug {
+				log.Printf("concurrent request %d failed: %v\n", i+1, result.err)
+			}
+			continue
+		}
+
+		ctx.history = append(ctx.history, exchange{request: result.request, response: result.response})
+
+		if last == nil || result.finished.After(last.finished) {
+			last = result
+		}
+	}
+
+	if last != nil {
+		ctx.lastRequest = last.request
+		ctx.lastResponse = last.response
+	}
+
+	return nil
+}
+
+// doBurstRequest Sends a request of a burst and reads its response, without changing the state of the context.
+func (ctx *ApiContext) doBurstRequest(req *http.Request) (*ApiResponse, error) {
+	resp, err := ctx.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rawBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newApiResponse(resp, rawBody), nil
+}
+
+// countBurstStatus Returns the number of responses of the last burst with the specified status code.
+func (ctx *ApiContext) countBurstStatus(statusCode int) int {
+	count := 0
+	for _, result := range ctx.burstResults {
+		if result.response != nil && result.response.StatusCode == statusCode {
+			count++
+		}
+	}
+
+	return count
+}
+
+// burstStatusSummary Describes the status codes of the last burst, ex: "201 x1, 409 x19",
+// followed by the requests that failed without a response.
+func (ctx *ApiContext) burstStatusSummary() string {
+	counts := map[int]int{}
+	var failures []string
+	for i, result := range ctx.burstResults {
+		if result.err != nil {
+			failures = append(failures, fmt.Sprintf(" - request %d: %v", i+1, result.err))
+			continue
+		}
+		counts[result.response.StatusCode]++
+	}
+
+	statusCodes := make([]int, 0, len(counts))
+	for statusCode := range counts {
+		statusCodes = append(statusCodes, statusCode)
+	}
+	sort.Ints(statusCodes)
+
+	parts := make([]string, len(statusCodes))
+	for i, statusCode := range statusCodes {
+		parts[i] = fmt.Sprintf("%d x%d", statusCode, counts[statusCode])
+	}
+
+	if len(failures) > 0 {
+		parts = append(parts, fmt.Sprintf("no response x%d", len(failures)))
+		return strings.Join(parts, ", ") + "\n" + strings.Join(failures, "\n")
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,90 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_ConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	orders := map[string]bool{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			time.Sleep(20 * time.Millisecond)
+			return
+		}
+
+		key := r.Header.Get("Idempotency-Key")
+
+		mu.Lock()
+		exists := orders[key]
+		orders[key] = true
+		mu.Unlock()
+
+		if exists {
+			w.WriteHeader(http.StatusConflict)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write([]byte(`{"key": "` + key + `"}`))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.ErrorIs(t, ctx.AllResponsesShouldHaveStatus(200), ErrNoResponse)
+
+	assert.Nil(t, ctx.ISendConcurrentRequestsTo(10, "GET", "/orders"))
+	assert.Nil(t, ctx.AllResponsesShouldHaveStatus(200))
+	assert.Nil(t, ctx.PercentileResponseTimeShouldBeBelow(95, 5000))
+	assert.Error(t, ctx.PercentileResponseTimeShouldBeBelow(50, 20))
+	assert.Error(t, ctx.PercentileResponseTimeShouldBeBelow(0, 20))
+	assert.Len(t, ctx.history, 10)
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Idempotency-Key", "order-1"))
+	assert.Nil(t, ctx.ISendConcurrentRequestsToWithBody(20, "POST", "/orders", &godog.DocString{Content: `{"product": "book"}`}))
+	assert.Nil(t, ctx.ExactlyResponsesShouldHaveStatus(1, 201))
+	assert.Nil(t, ctx.ExactlyResponsesShouldHaveStatus(19, 409))
+	assert.EqualError(t, ctx.AllResponsesShouldHaveStatus(201), "expected all 20 responses to have status 201, but got: 201 x1, 409 x19")
+	assert.EqualError(t, ctx.ExactlyResponsesShouldHaveStatus(2, 201), "expected exactly 2 response(s) to have status 201, but 1 have it. Statuses: 201 x1, 409 x19")
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.key", "order-1"))
+
+	assert.Error(t, ctx.ISendConcurrentRequestsTo(0, "GET", "/orders"))
+}
+
+func TestApiContext_ConcurrentRequestsWithoutResponse(t *testing.T) {
+	var count int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1)%2 == 0 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+			return
+		}
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISendConcurrentRequestsToWithBody(10, "POST", "/orders", &godog.DocString{Content: `{}`}))
+	assert.Nil(t, ctx.ExactlyResponsesShouldHaveStatus(5, 200))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(200))
+	assert.Nil(t, ctx.PercentileResponseTimeShouldBeBelow(95, 5000))
+	assert.Len(t, ctx.history, 5)
+
+	err := ctx.AllResponsesShouldHaveStatus(200)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expected all 10 responses to have status 200, but got: 200 x5, no response x5\n - request ")
+}
@@ -0,0 +1,181 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// WithNumberTolerance Configures the maximum difference allowed when comparing numbers, ex: 0.001.
+// By default numbers must be equal, which is also required to compare integers above 2^53 exactly.
+func (ctx *ApiContext) WithNumberTolerance(tolerance float64) *ApiContext {
+	ctx.numberTolerance = tolerance
+	return ctx
+}
+
+// TheJSONPathShouldHaveNumberValue Validates that the value at the specified json path is a number equal to the expected one.
+// Numbers are compared by value, so "1.0" matches 1, and big integers keep their precision.
+func (ctx *ApiContext) TheJSONPathShouldHaveNumberValue(pathExpr string, expectedValue string) error {
+	expectedValue, err := ctx.replaceScopeVariables(expectedValue)
+	if err != nil {
+		return err
+	}
+
+	expected, ok := toRat(json.Number(expectedValue))
+	if !ok {
+		return fmt.Errorf("%q is not a valid number", expectedValue)
+	}
+
+	actualValue, err := ctx.JSONPath(pathExpr)
+	if err != nil {
+		return err
+	}
+
+	actual, ok := toRat(actualValue)
+	if !ok {
+		return fmt.Errorf("expected json path %s to be a number, but its type is %s", pathExpr, jsonType(actualValue))
+	}
+
+	if !ctx.ratsEqual(actual, expected) {
+		return fmt.Errorf("expected json path %s to have number value %s but it is %s", pathExpr, expectedValue, jsonLiteral(actualValue))
+	}
+
+	return nil
+}
+
+// TheJSONPathShouldHaveStringValue Validates that the value at the specified json path is a string equal to the expected one.
+func (ctx *ApiContext) TheJSONPathShouldHaveStringValue(pathExpr string, expectedValue string) error {
+	expectedValue, err := ctx.replaceScopeVariables(expectedValue)
+	if err != nil {
+		return err
+	}
+
+	actualValue, err := ctx.JSONPath(pathExpr)
+	if err != nil {
+		return err
+	}
+
+	actual, ok := actualValue.(string)
+	if !ok {
+		return fmt.Errorf("expected json path %s to be a string, but its type is %s", pathExpr, jsonType(actualValue))
+	}
+
+	if actual != expectedValue {
+		return fmt.Errorf("expected json path %s to have string value %s but it is %s", pathExpr, expectedValue, actual)
+	}
+
+	return nil
+}
+
+// valuesEqual Compares two decoded JSON values. Numbers are compared by value, whatever their type,
+// using the configured tolerance, while objects and arrays are compared field by field.
+func (ctx *ApiContext) valuesEqual(actual, expected interface{}) bool {
+	return valuesEqual(actual, expected, ctx.numberTolerance)
+}
+
+// ratsEqual Compares two numbers using the configured tolerance.
+func (ctx *ApiContext) ratsEqual(actual, expected *big.Rat) bool {
+	return ratsEqual(actual, expected, ctx.numberTolerance)
+}
+
+// valuesEqual Compares two decoded JSON values, allowing numbers to differ by tolerance.
+func valuesEqual(actual, expected interface{}, tolerance float64) bool {
+	if a, ok := toRat(actual); ok {
+		e, ok := toRat(expected)
+		return ok && ratsEqual(a, e, tolerance)
+	}
+
+	switch a := actual.(type) {
+	case map[string]interface{}:
+		e, ok := expected.(map[string]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+
+		for key, value := range a {
+			expectedValue, ok := e[key]
+			if !ok || !valuesEqual(value, expectedValue, tolerance) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		e, ok := expected.([]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+
+		for i := range a {
+			if !valuesEqual(a[i], e[i], tolerance) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return reflect.DeepEqual(actual, expected)
+}
+
+// ratsEqual Checks that two numbers differ at most by tolerance. A tolerance of zero requires them to be equal.
+func ratsEqual(a, b *big.Rat, tolerance float64) bool {
+	if tolerance <= 0 {
+		return a.Cmp(b) == 0
+	}
+
+	diff := new(big.Rat).Sub(a, b)
+	max, _ := toRat(tolerance)
+
+	return diff.Abs(diff).Cmp(max) <= 0
+}
+
+// toRat Converts a number, decoded as json.Number, float64, an integer or *big.Int, to a rational number.
+func toRat(value interface{}) (*big.Rat, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		if !json.Valid([]byte(v)) {
+			return nil, false
+		}
+		return new(big.Rat).SetString(string(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		// The shortest representation matches the decimal literal the float was decoded from, ex: 0.1.
+		return new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case *big.Int:
+		return new(big.Rat).SetInt(v), true
+	default:
+		return nil, false
+	}
+}
+
+// jsonType Returns the name of the JSON type of a decoded value.
+func jsonType(value interface{}) string {
+	if _, ok := toRat(value); ok {
+		return "number"
+	}
+
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,69 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_NumericJSONPathAssertions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 9007199254740993, "count": 1, "price": 19.99, "ratio": 0.1, "code": "123", "tags": [1, 2.0], "missing": null}`))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.id", "9007199254740993"))
+	assert.Error(t, ctx.TheJSONPathShouldHaveValue("$.id", "9007199254740992"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.count", "1.0"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.ratio", "1e-1"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.code", "123"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.tags", "[1.0, 2]"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.missing", "null"))
+	assert.EqualError(t, ctx.TheJSONPathShouldHaveValue("$.count", "one"), "expected json path to have value one but it is 1")
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveNumberValue("$.id", "9007199254740993"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveNumberValue("$.count", "1.0"))
+	assert.EqualError(t, ctx.TheJSONPathShouldHaveNumberValue("$.price", "20"), "expected json path $.price to have number value 20 but it is 19.99")
+	assert.EqualError(t, ctx.TheJSONPathShouldHaveNumberValue("$.code", "123"), "expected json path $.code to be a number, but its type is string")
+	assert.EqualError(t, ctx.TheJSONPathShouldHaveNumberValue("$.count", "1/1"), `"1/1" is not a valid number`)
+
+	assert.Nil(t, ctx.TheJSONPathShouldHaveStringValue("$.code", "123"))
+	assert.EqualError(t, ctx.TheJSONPathShouldHaveStringValue("$.count", "1"), "expected json path $.count to be a string, but its type is number")
+
+	ctx.WithNumberTolerance(0.01)
+	assert.Nil(t, ctx.TheJSONPathShouldHaveNumberValue("$.price", "19.995"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.price", "19.98"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveNumberValue("$.price", "20"))
+	assert.Error(t, ctx.TheJSONPathShouldHaveNumberValue("$.price", "20.01"))
+}
+
+func TestValuesEqual(t *testing.T) {
+	actual, err := decodeJSON(`{"a": [1, 2.50, {"b": 12345678901234567890}], "c": "1"}`)
+	assert.Nil(t, err)
+
+	assert.False(t, valuesEqual(actual, map[string]interface{}{
+		"a": []interface{}{1, 2.5, map[string]interface{}{"b": "x"}},
+		"c": "1",
+	}, 0))
+
+	expected, err := decodeJSON(`{"c": "1", "a": [1.0, 2.5, {"b": 12345678901234567890}]}`)
+	assert.Nil(t, err)
+	assert.True(t, valuesEqual(actual, expected, 0))
+
+	expected, err = decodeJSON(`{"c": 1, "a": [1, 2.5, {"b": 12345678901234567890}]}`)
+	assert.Nil(t, err)
+	assert.False(t, valuesEqual(actual, expected, 0))
+
+	expected, err = decodeJSON(`{"c": "1", "a": [1, 2.5, {"b": 12345678901234567891}]}`)
+	assert.Nil(t, err)
+	assert.False(t, valuesEqual(actual, expected, 0))
+	assert.True(t, valuesEqual(actual, expected, 1))
+}
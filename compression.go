@@ -0,0 +1,173 @@
+package apicontext
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// TheResponseContentEncodingShouldBe Checks the Content-Encoding of the response, ex: gzip.
+// A response without Content-Encoding has the "identity" encoding.
+func (ctx *ApiContext) TheResponseContentEncodingShouldBe(expectedEncoding string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	if response.decodeErr != nil {
+		return response.decodeErr
+	}
+
+	actualEncoding := contentEncoding(response)
+	if !strings.EqualFold(actualEncoding, strings.TrimSpace(expectedEncoding)) {
+		return fmt.Errorf("expected content encoding to be %s. actual : %s", expectedEncoding, actualEncoding)
+	}
+
+	return nil
+}
+
+// TheResponseCompressionRatioShouldBeAtLeast Checks the ratio between the decoded and the raw size of the response body.
+// Ex: a ratio of 4 means the body is at least 4 times smaller on the wire.
+func (ctx *ApiContext) TheResponseCompressionRatioShouldBeAtLeast(expectedRatio float64) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	if response.decodeErr != nil {
+		return response.decodeErr
+	}
+
+	if len(response.RawBody) == 0 {
+		return fmt.Errorf("cannot compute the compression ratio of an empty response body")
+	}
+
+	if response.ResponseObj != nil && response.ResponseObj.Uncompressed {
+		return fmt.Errorf("cannot compute the compression ratio of a response decompressed by the http client")
+	}
+
+	ratio := float64(len(response.Body)) / float64(len(response.RawBody))
+	if ratio < expectedRatio {
+		return fmt.Errorf("expected compression ratio to be at least %.2f, but actual is %.2f (%d bytes decoded to %d bytes, content encoding %s)",
+			expectedRatio, ratio, len(response.RawBody), len(response.Body), contentEncoding(response))
+	}
+
+	return nil
+}
+
+// contentEncoding Returns the Content-Encoding of a response, or "identity" when it has none.
+// Responses decompressed transparently by the http.Client have lost their header, and were gzip encoded.
+func contentEncoding(response *ApiResponse) string {
+	if response.ResponseObj != nil && response.ResponseObj.Uncompressed {
+		return "gzip"
+	}
+
+	if response.ResponseObj == nil || response.ResponseObj.Header.Get("Content-Encoding") == "" {
+		return "identity"
+	}
+
+	return response.ResponseObj.Header.Get("Content-Encoding")
+}
+
+// acceptGzip Advertises gzip, like the http.Client does, when the request does not set Accept-Encoding itself.
+// Setting the header disables the transparent decompression of the http.Client, so the response keeps
+// its Content-Encoding and its raw body, and is decoded by newApiResponse.
+func acceptGzip(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" && req.Method != http.MethodHead {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+}
+
+// maxDecodedBodySize The maximum size of a decoded response body, to protect against decompression bombs.
+const maxDecodedBodySize = 64 << 20
+
+// newApiResponse Creates the response of a request, decoding its raw body with the Content-Encoding of the response.
+// A body that cannot be decoded is kept as is, and the error is reported by the content encoding steps,
+// so the status code and headers of the response can still be checked.
+func newApiResponse(resp *http.Response, rawBody []byte) *ApiResponse {
+	response := &ApiResponse{
+		StatusCode:  resp.StatusCode,
+		ResponseObj: resp,
+		Body:        string(rawBody),
+		RawBody:     rawBody,
+	}
+
+	body, err := decodeBody(resp.Header.Get("Content-Encoding"), rawBody)
+	if err != nil {
+		response.decodeErr = err
+		return response
+	}
+
+	response.Body = string(body)
+
+	return response
+}
+
+// decodeBody Decodes a body with the encodings of a Content-Encoding header, in the reverse order they were applied.
+// The http.Client only decodes gzip transparently, and not when the request sets Accept-Encoding itself.
+func decodeBody(encodings string, body []byte) ([]byte, error) {
+	if encodings == "" {
+		return body, nil
+	}
+
+	names := strings.Split(encodings, ",")
+	for i := len(names) - 1; i >= 0; i-- {
+		name := strings.ToLower(strings.TrimSpace(names[i]))
+
+		reader, err := newDecoder(name, body)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode %s response body: %v", name, err)
+		}
+
+		if reader == nil {
+			continue
+		}
+
+		body, err = io.ReadAll(io.LimitReader(reader, maxDecodedBodySize+1))
+		_ = reader.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode %s response body: %v", name, err)
+		}
+
+		if len(body) > maxDecodedBodySize {
+			return nil, fmt.Errorf("cannot decode %s response body: it exceeds %d bytes once decoded", name, maxDecodedBodySize)
+		}
+	}
+
+	return body, nil
+}
+
+// newDecoder Returns a reader decoding a body with the specified content encoding, or nil for the identity encoding.
+func newDecoder(name string, body []byte) (io.ReadCloser, error) {
+	switch name {
+	case "", "identity":
+		return nil, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		// Most servers send zlib wrapped data for deflate, some send raw deflate data.
+		if reader, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			return reader, nil
+		}
+		return flate.NewReader(bytes.NewReader(body)), nil
+	case "br":
+		return io.NopCloser(brotli.NewReader(bytes.NewReader(body))), nil
+	case "zstd":
+		decoder, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding")
+	}
+}
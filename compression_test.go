@@ -0,0 +1,154 @@
+package apicontext
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+var compressedBody = `{"items": [` + strings.Repeat(`{"name": "item", "price": 10},`, 50) + `{"name": "last", "price": 1}]}`
+
+// compress Encodes a body with the specified content encoding.
+func compress(t *testing.T, encoding string, body string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		w, _ = zstd.NewWriter(&buf)
+	}
+
+	_, err := w.Write([]byte(body))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func TestApiContext_DecodeCompressedResponses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.TrimPrefix(r.URL.Path, "/")
+		w.Header().Set("Content-Type", "application/json")
+
+		if encoding == "compress" || encoding == "corrupt" {
+			w.Header().Set("Content-Encoding", strings.Replace(encoding, "corrupt", "gzip", 1))
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("oops"))
+			return
+		}
+
+		if encoding == "identity" {
+			_, _ = w.Write([]byte(compressedBody))
+			return
+		}
+
+		if encoding == "raw-deflate" {
+			w.Header().Set("Content-Encoding", "deflate")
+		} else {
+			w.Header().Set("Content-Encoding", encoding)
+		}
+		_, _ = w.Write(compress(t, encoding, compressedBody))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Accept-Encoding", "gzip, deflate, br, zstd"))
+
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate", "br", "zstd"} {
+		assert.Nil(t, ctx.ISendRequestTo("GET", "/"+encoding), encoding)
+		assert.Equal(t, compressedBody, ctx.LastResponse().Body, encoding)
+		assert.NotEqual(t, compressedBody, string(ctx.LastResponse().RawBody), encoding)
+		assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.items[50].name", "last"), encoding)
+		assert.Nil(t, ctx.TheResponseCompressionRatioShouldBeAtLeast(5), encoding)
+	}
+
+	assert.Nil(t, ctx.TheResponseContentEncodingShouldBe("zstd"))
+	assert.EqualError(t, ctx.TheResponseContentEncodingShouldBe("gzip"), "expected content encoding to be gzip. actual : zstd")
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/identity"))
+	assert.Nil(t, ctx.TheResponseContentEncodingShouldBe("identity"))
+	assert.Equal(t, compressedBody, string(ctx.LastResponse().RawBody))
+	assert.Error(t, ctx.TheResponseCompressionRatioShouldBeAtLeast(1.5))
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/compress"))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(500))
+	assert.Equal(t, "oops", ctx.LastResponse().Body)
+	assert.EqualError(t, ctx.TheResponseContentEncodingShouldBe("compress"), "cannot decode compress response body: unsupported content encoding")
+	assert.Error(t, ctx.TheResponseCompressionRatioShouldBeAtLeast(1))
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/corrupt"))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(500))
+	assert.Error(t, ctx.TheResponseContentEncodingShouldBe("gzip"))
+}
+
+func TestApiContext_DefaultGzipResponses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = w.Write([]byte(compressedBody))
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compress(t, "gzip", compressedBody))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheResponseContentEncodingShouldBe("gzip"))
+	assert.Nil(t, ctx.TheResponseCompressionRatioShouldBeAtLeast(5))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.items[50].name", "last"))
+
+	assert.Nil(t, ctx.ISendConcurrentRequestsTo(2, "GET", "/"))
+	assert.Nil(t, ctx.TheResponseContentEncodingShouldBe("gzip"))
+	assert.Equal(t, compressedBody, ctx.LastResponse().Body)
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("Accept-Encoding", "identity"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheResponseContentEncodingShouldBe("identity"))
+}
+
+func TestContentEncoding(t *testing.T) {
+	response := &ApiResponse{Body: compressedBody, RawBody: []byte(compressedBody), ResponseObj: &http.Response{Header: http.Header{}, Uncompressed: true}}
+	assert.Equal(t, "gzip", contentEncoding(response))
+	assert.Error(t, (&ApiContext{lastResponse: response}).TheResponseCompressionRatioShouldBeAtLeast(1))
+}
+
+func TestDecodeBody(t *testing.T) {
+	body, err := decodeBody("gzip, br", compress(t, "br", string(compress(t, "gzip", "hello"))))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(body))
+
+	_, err = decodeBody("compress", []byte("hello"))
+	assert.EqualError(t, err, "cannot decode compress response body: unsupported content encoding")
+
+	_, err = decodeBody("gzip", []byte("hello"))
+	assert.Error(t, err)
+
+	_, err = decodeBody("gzip", compress(t, "gzip", strings.Repeat("a", maxDecodedBodySize+1)))
+	assert.EqualError(t, err, "cannot decode gzip response body: it exceeds 67108864 bytes once decoded")
+}
@@ -0,0 +1,441 @@
+package apicontext
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables that override the values of the configuration file.
+const (
+	envConfigBaseURL = "API_CONTEXT_BASE_URL"
+	envConfigProfile = "API_CONTEXT_PROFILE"
+	envConfigTimeout = "API_CONTEXT_TIMEOUT"
+	envConfigDebug   = "API_CONTEXT_DEBUG"
+)
+
+// configEnvPattern Matches the references to environment variables in a configuration file, ex: "${API_TOKEN}".
+var configEnvPattern = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
+// Config Defines the settings of an ApiContext that can be loaded from a YAML or JSON file.
+// References to environment variables like "${API_TOKEN}" are expanded in the string values of the file.
+type Config struct {
+	BaseURL         string                 `yaml:"baseURL"`
+	BaseURLs        map[string]string      `yaml:"baseURLs"`
+	Headers         map[string]string      `yaml:"headers"`
+	QueryParams     map[string]string      `yaml:"queryParams"`
+	Timeout         string                 `yaml:"timeout"`
+	Debug           bool                   `yaml:"debug"`
+	NumberTolerance float64                `yaml:"numberTolerance"`
+	TLS             TLSConfig              `yaml:"tls"`
+	SchemasPath     string                 `yaml:"schemasPath"`
+	FixturesPath    string                 `yaml:"fixturesPath"`
+	Snapshots       SnapshotsConfig        `yaml:"snapshots"`
+	Auth            AuthConfig             `yaml:"auth"`
+	GRPC            GRPCConfig             `yaml:"grpc"`
+	Variables       map[string]interface{} `yaml:"variables"`
+	Profile         string                 `yaml:"profile"`
+	Profiles        map[string]Profile     `yaml:"profiles"`
+}
+
+// TLSConfig Defines the TLS settings of the HTTP client.
+type TLSConfig struct {
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	CAFile             string `yaml:"caFile"`
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+}
+
+// SnapshotsConfig Defines where response snapshots are stored and which fields are masked.
+type SnapshotsConfig struct {
+	Path   string   `yaml:"path"`
+	Mask   []string `yaml:"mask"`
+	Update bool     `yaml:"update"`
+}
+
+// AuthConfig Defines the credentials sent with every request.
+type AuthConfig struct {
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	BearerToken string `yaml:"bearerToken"`
+}
+
+// GRPCConfig Defines how gRPC methods are resolved.
+type GRPCConfig struct {
+	DescriptorSets []string `yaml:"descriptorSets"`
+}
+
+// Profile Defines the settings of an environment, which override the top level settings when the profile is selected.
+type Profile struct {
+	BaseURL   string                 `yaml:"baseURL"`
+	BaseURLs  map[string]string      `yaml:"baseURLs"`
+	Headers   map[string]string      `yaml:"headers"`
+	Auth      *AuthConfig            `yaml:"auth"`
+	Variables map[string]interface{} `yaml:"variables"`
+}
+
+// NewFromConfig Creates a new instance of the API Context from a YAML or JSON configuration file.
+func NewFromConfig(path string) (*ApiContext, error) {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithConfig(config)
+}
+
+// LoadConfig Reads, applies the environment overrides and validates a configuration file.
+func LoadConfig(path string) (*Config, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, fmt.Errorf("cannot parse config file %s: %v", path, err)
+	}
+
+	if errs := expandConfigEnv(&document); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config file %s: invalid settings:\n - %s", path, strings.Join(errs, "\n - "))
+	}
+
+	config := &Config{}
+	if err := document.Decode(config); err != nil {
+		return nil, fmt.Errorf("cannot parse config file %s: %v", path, err)
+	}
+
+	if err := config.applyEnvOverrides(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	if err := config.applyProfile(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// expandConfigEnv Replaces the "${VAR}" references in the scalar values of a parsed configuration file
+// with the value of the environment variable. Other "$" characters are kept as is.
+// It returns an error for every reference to a variable that is not set.
+func expandConfigEnv(node *yaml.Node) []string {
+	var errs []string
+
+	if node.Kind == yaml.ScalarNode {
+		if !configEnvPattern.MatchString(node.Value) {
+			return nil
+		}
+
+		node.Value = configEnvPattern.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			name := ref[2 : len(ref)-1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				errs = append(errs, fmt.Sprintf("line %d: environment variable %s is not set", node.Line, name))
+			}
+
+			return value
+		})
+
+		// Unquoted values are resolved again, so "debug: ${DEBUG}" can hold a bool.
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+
+		return errs
+	}
+
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+
+		errs = append(errs, expandConfigEnv(child)...)
+	}
+
+	return errs
+}
+
+// NewWithConfig Creates a new instance of the API Context from a configuration.
+func NewWithConfig(config *Config) (*ApiContext, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+
+	ctx := New(config.BaseURL).
+		WithDebug(config.Debug).
+		WithNumberTolerance(config.NumberTolerance)
+
+	if config.SchemasPath != "" {
+		ctx.WithJSONSchemasPath(config.SchemasPath)
+	}
+
+	if config.FixturesPath != "" {
+		ctx.WithFixturesPath(config.FixturesPath)
+	}
+
+	if config.Snapshots.Path != "" {
+		ctx.WithSnapshotsPath(config.Snapshots.Path)
+	}
+
+	ctx.WithSnapshotMask(config.Snapshots.Mask...).WithSnapshotUpdate(config.Snapshots.Update)
+	ctx.WithGRPCDescriptorSets(config.GRPC.DescriptorSets...)
+
+	for name, baseURL := range config.BaseURLs {
+		ctx.WithNamedBaseURL(name, baseURL)
+	}
+
+	if config.Timeout != "" {
+		timeout, _ := time.ParseDuration(config.Timeout)
+		ctx.client.Timeout = timeout
+	}
+
+	tlsConfig, err := config.TLS.build()
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		ctx.client.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		}
+	}
+
+	ctx.WithDefaultHeaders(config.Headers)
+
+	if authorization := config.Auth.header(); authorization != "" {
+		ctx.WithDefaultHeaders(map[string]string{"Authorization": authorization})
+	}
+
+	ctx.WithDefaultQueryParams(config.QueryParams)
+
+	for key, value := range config.Variables {
+		if err := ctx.scope.Set(GlobalScope, key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctx, nil
+}
+
+// Validate Checks the configuration and reports every invalid setting.
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.BaseURL == "" {
+		errs = append(errs, "baseURL is required")
+	} else if err := validateBaseURL(c.BaseURL); err != nil {
+		errs = append(errs, fmt.Sprintf("baseURL: %v", err))
+	}
+
+	names := make([]string, 0, len(c.BaseURLs))
+	for name := range c.BaseURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := validateBaseURL(c.BaseURLs[name]); err != nil {
+			errs = append(errs, fmt.Sprintf("baseURLs.%s: %v", name, err))
+		}
+	}
+
+	if c.Timeout != "" {
+		if timeout, err := time.ParseDuration(c.Timeout); err != nil {
+			errs = append(errs, fmt.Sprintf("timeout: %q is not a valid duration, ex: 30s", c.Timeout))
+		} else if timeout < 0 {
+			errs = append(errs, "timeout: must not be negative")
+		}
+	}
+
+	if c.NumberTolerance < 0 {
+		errs = append(errs, "numberTolerance: must not be negative")
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, "tls: certFile and keyFile must be specified together")
+	}
+
+	for field, path := range map[string]string{"tls.caFile": c.TLS.CAFile, "tls.certFile": c.TLS.CertFile, "tls.keyFile": c.TLS.KeyFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: file %s does not exist", field, path))
+		}
+	}
+
+	for _, path := range c.GRPC.DescriptorSets {
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Sprintf("grpc.descriptorSets: file %s does not exist", path))
+		}
+	}
+
+	for _, mask := range c.Snapshots.Mask {
+		if _, err := parseMaskPath(mask); err != nil {
+			errs = append(errs, fmt.Sprintf("snapshots.mask: %v", err))
+		}
+	}
+
+	if c.Auth.BearerToken != "" && (c.Auth.Username != "" || c.Auth.Password != "") {
+		errs = append(errs, "auth: bearerToken cannot be combined with username and password")
+	}
+
+	if c.Auth.Password != "" && c.Auth.Username == "" {
+		errs = append(errs, "auth: username is required when password is set")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.Strings(errs)
+
+	return fmt.Errorf("invalid settings:\n - %s", strings.Join(errs, "\n - "))
+}
+
+// applyEnvOverrides Overrides the configuration with the values of the API_CONTEXT_* environment variables.
+func (c *Config) applyEnvOverrides() error {
+	if value, ok := os.LookupEnv(envConfigBaseURL); ok {
+		c.BaseURL = value
+	}
+
+	if value, ok := os.LookupEnv(envConfigProfile); ok {
+		c.Profile = value
+	}
+
+	if value, ok := os.LookupEnv(envConfigTimeout); ok {
+		c.Timeout = value
+	}
+
+	if value, ok := os.LookupEnv(envConfigDebug); ok {
+		debug, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a valid boolean", envConfigDebug, value)
+		}
+		c.Debug = debug
+	}
+
+	return nil
+}
+
+// applyProfile Merges the selected profile into the top level settings.
+func (c *Config) applyProfile() error {
+	if c.Profile == "" {
+		return nil
+	}
+
+	profile, ok := c.Profiles[c.Profile]
+	if !ok {
+		return fmt.Errorf("profile %q is not defined", c.Profile)
+	}
+
+	if profile.BaseURL != "" && os.Getenv(envConfigBaseURL) == "" {
+		c.BaseURL = profile.BaseURL
+	}
+
+	if profile.Auth != nil {
+		c.Auth = *profile.Auth
+	}
+
+	c.BaseURLs = mergeStrings(c.BaseURLs, profile.BaseURLs)
+	c.Headers = mergeStrings(c.Headers, profile.Headers)
+
+	if c.Variables == nil {
+		c.Variables = make(map[string]interface{})
+	}
+	for key, value := range profile.Variables {
+		c.Variables[key] = value
+	}
+
+	return nil
+}
+
+// build Creates the TLS configuration of the HTTP client. It returns nil when no TLS setting is defined.
+func (c TLSConfig) build() (*tls.Config, error) {
+	if c == (TLSConfig{}) {
+		return nil, nil
+	}
+
+	// #nosec G402 -- skipping verification is an explicit opt-in for test environments with self-signed certificates.
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+
+	if c.CAFile != "" {
+		ca, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no valid certificates found in CA file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// header Returns the value of the Authorization header for the configured credentials.
+func (a AuthConfig) header() string {
+	switch {
+	case a.BearerToken != "":
+		return "Bearer " + a.BearerToken
+	case a.Username != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password))
+	default:
+		return ""
+	}
+}
+
+// validateBaseURL Checks that a base URL is an absolute HTTP URL.
+func validateBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid URL", baseURL)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%q must be an absolute http or https URL", baseURL)
+	}
+
+	return nil
+}
+
+// mergeStrings Returns a map with the values of base overridden by the values of override.
+func mergeStrings(base map[string]string, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+
+	return merged
+}
@@ -0,0 +1,114 @@
+package apicontext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	assert.Nil(t, os.Setenv("GODOG_TEST_TOKEN", "secret"))
+	defer func() { _ = os.Unsetenv("GODOG_TEST_TOKEN") }()
+
+	ctx, err := NewFromConfig(filepath.Join("testdata", "config", "config.yml"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080", ctx.baseURL)
+	assert.Equal(t, "https://users.example.com", ctx.baseURLs["users"])
+	assert.Equal(t, "testdata/schemas", ctx.jSONSchemasPath)
+	assert.Equal(t, "testdata", ctx.fixturesPath)
+	assert.Equal(t, 5*time.Second, ctx.client.Timeout)
+	assert.Equal(t, 0.01, ctx.numberTolerance)
+	assert.Equal(t, "application/json", ctx.headers.Get("Accept"))
+	assert.Equal(t, "Bearer secret", ctx.headers.Get("Authorization"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("tenant", "local"))
+}
+
+func TestNewFromConfig_ProfileFromEnv(t *testing.T) {
+	assert.Nil(t, os.Setenv("GODOG_TEST_TOKEN", "secret"))
+	defer func() { _ = os.Unsetenv("GODOG_TEST_TOKEN") }()
+	assert.Nil(t, os.Setenv("API_CONTEXT_PROFILE", "staging"))
+	defer func() { _ = os.Unsetenv("API_CONTEXT_PROFILE") }()
+
+	ctx, err := NewFromConfig(filepath.Join("testdata", "config", "config.yml"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "https://staging.example.com", ctx.baseURL)
+	assert.Equal(t, "staging", ctx.headers.Get("X-Env"))
+	assert.Equal(t, "Basic dXNlcjpwYXNz", ctx.headers.Get("Authorization"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("tenant", "acme"))
+}
+
+func TestNewFromConfig_EnvOverrides(t *testing.T) {
+	assert.Nil(t, os.Setenv("GODOG_TEST_TOKEN", "secret"))
+	defer func() { _ = os.Unsetenv("GODOG_TEST_TOKEN") }()
+	assert.Nil(t, os.Setenv("API_CONTEXT_BASE_URL", "https://override.example.com"))
+	defer func() { _ = os.Unsetenv("API_CONTEXT_BASE_URL") }()
+
+	ctx, err := NewFromConfig(filepath.Join("testdata", "config", "config.yml"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "https://override.example.com", ctx.baseURL)
+}
+
+func TestNewFromConfig_EnvReferences(t *testing.T) {
+	path := filepath.Join("testdata", "config", "env.yml")
+	assert.Nil(t, os.Setenv("GODOG_TEST_DEBUG", "true"))
+	assert.Nil(t, os.Setenv("GODOG_TEST_NOTE", "a: b # c"))
+	defer func() { _ = os.Unsetenv("GODOG_TEST_DEBUG") }()
+	defer func() { _ = os.Unsetenv("GODOG_TEST_NOTE") }()
+
+	_, err := LoadConfig(path)
+	assert.EqualError(t, err, `invalid config file testdata/config/env.yml: invalid settings:
+ - line 10: environment variable GODOG_TEST_RETRIES is not set
+ - line 11: environment variable GODOG_TEST_RETRIES is not set`)
+
+	assert.Nil(t, os.Setenv("GODOG_TEST_RETRIES", "3"))
+	defer func() { _ = os.Unsetenv("GODOG_TEST_RETRIES") }()
+
+	config, err := LoadConfig(path)
+	assert.Nil(t, err)
+	assert.True(t, config.Debug)
+	assert.Equal(t, map[string]string{"X-Price": "$5", "X-Note": "a: b # c"}, config.Headers)
+	assert.Equal(t, "pa$$w0rd$HOME", config.Auth.Password)
+	assert.Equal(t, map[string]interface{}{"retries": 3, "code": "3"}, config.Variables)
+}
+
+func TestNewFromConfig_JSON(t *testing.T) {
+	ctx, err := NewFromConfig(filepath.Join("testdata", "config", "config.json"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com", ctx.baseURL)
+	assert.True(t, ctx.debug)
+	assert.Equal(t, "application/json", ctx.headers.Get("Accept"))
+}
+
+func TestNewFromConfig_Invalid(t *testing.T) {
+	_, err := NewFromConfig(filepath.Join("testdata", "config", "invalid.yml"))
+
+	assert.EqualError(t, err, `invalid config file testdata/config/invalid.yml: invalid settings:
+ - auth: bearerToken cannot be combined with username and password
+ - baseURL: "example.com" must be an absolute http or https URL
+ - baseURLs.users: "ftp://users.example.com" must be an absolute http or https URL
+ - grpc.descriptorSets: file missing.pb does not exist
+ - numberTolerance: must not be negative
+ - timeout: "soon" is not a valid duration, ex: 30s
+ - tls.certFile: file missing.pem does not exist
+ - tls: certFile and keyFile must be specified together`)
+
+	_, err = NewFromConfig(filepath.Join("testdata", "config", "missing.yml"))
+	assert.Error(t, err)
+}
+
+func TestApiContext_IUseTheBaseURL(t *testing.T) {
+	ctx := setupTestContext().WithNamedBaseURL("users", "https://users.example.com")
+
+	assert.Nil(t, ctx.IUseTheBaseURL("users"))
+	reqURL, err := ctx.requestURL("/me")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://users.example.com/me", reqURL)
+	assert.Error(t, ctx.IUseTheBaseURL("orders"))
+}
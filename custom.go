@@ -0,0 +1,74 @@
+package apicontext
+
+import (
+	"io"
+	"net/http"
+)
+
+// LastResponse Returns the response of the last request sent in the current scenario, or nil if no request was sent.
+func (ctx *ApiContext) LastResponse() *ApiResponse {
+	return ctx.lastResponse
+}
+
+// LastRequest Returns the last request sent in the current scenario, or nil if no request was sent.
+func (ctx *ApiContext) LastRequest() *http.Request {
+	return ctx.lastRequest
+}
+
+// Scope Returns the scope variables, shared with the built-in steps.
+func (ctx *ApiContext) Scope() *Scope {
+	return ctx.scope
+}
+
+// SetHeader Sets a header that is sent with the next requests of the current scenario.
+func (ctx *ApiContext) SetHeader(name string, value string) {
+	ctx.headers.Set(name, value)
+}
+
+// ExpandScopeVariables Replaces the scope placeholders of data, like the built-in steps do, ex: "`##token`" or "`##user.id:-1`".
+// In strict mode, it returns an error when a variable cannot be resolved.
+func (ctx *ApiContext) ExpandScopeVariables(data string) (string, error) {
+	return ctx.replaceScopeVariables(data)
+}
+
+// ExpandScopeVariablesInJSON Replaces the scope placeholders of a JSON document, like the request body steps do.
+// Placeholders outside string literals are replaced by the JSON literal of the value, preserving its type.
+// In strict mode, it returns an error when a variable cannot be resolved.
+func (ctx *ApiContext) ExpandScopeVariablesInJSON(data string) (string, error) {
+	return ctx.replaceScopeVariablesInJSON(data)
+}
+
+// JSON Returns the decoded JSON body of the last response.
+func (ctx *ApiContext) JSON() (interface{}, error) {
+	response, err := ctx.response()
+	if err != nil {
+		return nil, err
+	}
+
+	return response.JSON()
+}
+
+// JSONPath Returns the value at the specified json path of the last response body.
+func (ctx *ApiContext) JSONPath(pathExpr string) (interface{}, error) {
+	response, err := ctx.response()
+	if err != nil {
+		return nil, err
+	}
+
+	return response.JSONPath(pathExpr)
+}
+
+// Send Sends a request to the specified endpoint, with the base URL, headers, query params and path params
+// of the current scenario. The response becomes the last response, checked by the assertion steps.
+func (ctx *ApiContext) Send(method string, uri string, body io.Reader) (*ApiResponse, error) {
+	req, err := ctx.newRequest(method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.sendRequest(req); err != nil {
+		return nil, err
+	}
+
+	return ctx.lastResponse, nil
+}
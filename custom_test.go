@@ -0,0 +1,73 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_CustomStepsAPI(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"path": %q, "tenant": %q, "body": %q}`, r.URL.Path, r.Header.Get("X-Tenant"), body)
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.LastResponse())
+	assert.Nil(t, ctx.LastRequest())
+
+	_, err := ctx.JSON()
+	assert.True(t, errors.Is(err, ErrNoResponse))
+
+	// A custom step like `I log in as "admin"` built on the same state and HTTP pipeline.
+	loginAs := func(user string) error {
+		assert.Nil(t, ctx.Scope().Set(ScenarioScope, "user", map[string]string{"name": user}))
+		ctx.SetHeader("X-Tenant", "acme")
+
+		response, err := ctx.Send("POST", "/users/{user.name}/login", strings.NewReader(`{"remember": true}`))
+		if err != nil {
+			return err
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("cannot log in as %s", user)
+		}
+
+		return nil
+	}
+
+	assert.Nil(t, loginAs("admin"))
+	assert.Equal(t, "/users/admin/login", ctx.LastRequest().URL.Path)
+	assert.Equal(t, "acme", ctx.LastRequest().Header.Get("X-Tenant"))
+	assert.Equal(t, http.StatusOK, ctx.LastResponse().StatusCode)
+
+	data, err := ctx.JSON()
+	assert.Nil(t, err)
+	assert.Equal(t, "acme", data.(map[string]interface{})["tenant"])
+
+	value, err := ctx.JSONPath("$.body")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"remember": true}`, value)
+
+	name, ok := ctx.Scope().Get("user.name")
+	assert.True(t, ok)
+	assert.Equal(t, "admin", name)
+
+	assert.Nil(t, ctx.Scope().Set(FeatureScope, "count", 2))
+	count, ok := ctx.Scope().Get("count")
+	assert.True(t, ok)
+	assert.Equal(t, json.Number("2"), count)
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.path", "/users/`##user.name`/login"))
+}
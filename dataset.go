@@ -0,0 +1,227 @@
+package apicontext
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cucumber/godog"
+)
+
+// datasetRowKey The scope variable holding the current dataset row, ex: `##row.email`.
+const datasetRowKey = "row"
+
+// datasetRow A row of a dataset and the response of the request sent for it.
+type datasetRow struct {
+	number   int
+	values   map[string]interface{}
+	response *ApiResponse
+}
+
+// ISendRequestToForEachRowIn Sends a request for every row of a CSV or JSON dataset.
+// The row is stored in the "row" scope variable, so the URI can refer to its columns, ex: /users/{row.id}.
+func (ctx *ApiContext) ISendRequestToForEachRowIn(method, uri string, path string) error {
+	return ctx.sendForEachRow(method, uri, path, func(row datasetRow) (io.Reader, error) {
+		return nil, nil
+	})
+}
+
+// ISendRequestToWithBodyFromFileForEachRowIn Sends a request for every row of a CSV or JSON dataset, with the row as JSON body.
+func (ctx *ApiContext) ISendRequestToWithBodyFromFileForEachRowIn(method, uri string, path string) error {
+	return ctx.sendForEachRow(method, uri, path, func(row datasetRow) (io.Reader, error) {
+		body, err := json.Marshal(row.values)
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.NewReader(body), nil
+	})
+}
+
+// ISendRequestToWithBodyForEachRowIn Sends a request for every row of a CSV or JSON dataset, with a JSON body template.
+// The template refers to the columns of the row with placeholders like `##row.email`.
+func (ctx *ApiContext) ISendRequestToWithBodyForEachRowIn(method, uri string, path string, requestBody *godog.DocString) error {
+	return ctx.sendForEachRow(method, uri, path, func(row datasetRow) (io.Reader, error) {
+		body, err := ctx.replaceScopeVariablesInJSON(requestBody.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.NewBufferString(body), nil
+	})
+}
+
+// TheResponseCodeForEachRowShouldBe Checks the status code of the response of every dataset row.
+func (ctx *ApiContext) TheResponseCodeForEachRowShouldBe(statusCode int) error {
+	return ctx.assertEachRow(func(row datasetRow) error {
+		if row.response.StatusCode != statusCode {
+			return fmt.Errorf("expected status code to be %d, but actual is %d. Response body: %s", statusCode, row.response.StatusCode, row.response.Body)
+		}
+
+		return nil
+	})
+}
+
+// TheJSONPathForEachRowShouldHaveValue Checks the value at the specified json path in the response of every dataset row.
+// The expected value can refer to the columns of the row, ex: `##row.email`.
+func (ctx *ApiContext) TheJSONPathForEachRowShouldHaveValue(pathExpr string, expectedValue string) error {
+	return ctx.assertEachRow(func(row datasetRow) error {
+		return ctx.jsonPathShouldHaveValue(row.response, pathExpr, expectedValue)
+	})
+}
+
+// sendForEachRow Loads a dataset and sends a request for each of its rows, with the row bound in the scenario scope.
+// A row whose request cannot be sent does not stop the next ones, and is reported at the end.
+func (ctx *ApiContext) sendForEachRow(method, uri string, path string, body func(row datasetRow) (io.Reader, error)) error {
+	path, err := ctx.fixturePath(path)
+	if err != nil {
+		return err
+	}
+
+	rows, err := loadDataset(path)
+	if err != nil {
+		return err
+	}
+
+	ctx.datasetRows = nil
+
+	var failures []string
+	for _, row := range rows {
+		if err := ctx.scope.Set(ScenarioScope, datasetRowKey, row.values); err != nil {
+			return err
+		}
+
+		reader, err := body(row)
+		if err == nil {
+			row.response, err = ctx.Send(method, uri, reader)
+		}
+
+		if err != nil {
+			failures = append(failures, formatRowFailure(row, err))
+			continue
+		}
+
+		ctx.history[len(ctx.history)-1].name = fmt.Sprintf("row %d", row.number)
+		ctx.datasetRows = append(ctx.datasetRows, row)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d row(s) of %s failed:\n%s", len(failures), len(rows), path, strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// assertEachRow Runs an assertion on the response of every dataset row, with the row bound in the scenario scope,
+// and reports all the failing rows.
+func (ctx *ApiContext) assertEachRow(assertion func(row datasetRow) error) error {
+	if len(ctx.datasetRows) == 0 {
+		return fmt.Errorf("%w: no request was sent for a dataset in this scenario", ErrNoResponse)
+	}
+
+	var failures []string
+	for _, row := range ctx.datasetRows {
+		if err := ctx.scope.Set(ScenarioScope, datasetRowKey, row.values); err != nil {
+			return err
+		}
+
+		if err := assertion(row); err != nil {
+			failures = append(failures, formatRowFailure(row, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d row(s) failed:\n%s", len(failures), len(ctx.datasetRows), strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// formatRowFailure Describes the failure of a dataset row.
+func formatRowFailure(row datasetRow, err error) string {
+	return fmt.Sprintf(" - row %d %s: %v", row.number, stringifyJSON(row.values), err)
+}
+
+// loadDataset Reads the rows of a CSV file, whose first line holds the column names, or of a JSON file holding an array of objects.
+func loadDataset(path string) ([]datasetRow, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read dataset: %w", err)
+	}
+
+	var rows []datasetRow
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = parseCSVDataset(contents)
+	case ".json":
+		rows, err = parseJSONDataset(contents)
+	default:
+		return nil, fmt.Errorf("unsupported dataset %s, expected a .csv or .json file", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid dataset %s: %v", path, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("dataset %s has no rows", path)
+	}
+
+	return rows, nil
+}
+
+// parseCSVDataset Parses a CSV dataset. The values are strings.
+func parseCSVDataset(contents []byte) ([]datasetRow, error) {
+	records, err := csv.NewReader(bytes.NewReader(contents)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	rows := make([]datasetRow, 0, len(records)-1)
+
+	for i, record := range records[1:] {
+		values := make(map[string]interface{}, len(header))
+		for j, name := range header {
+			values[strings.TrimSpace(name)] = record[j]
+		}
+
+		rows = append(rows, datasetRow{number: i + 1, values: values})
+	}
+
+	return rows, nil
+}
+
+// parseJSONDataset Parses a JSON dataset. The values keep their JSON types.
+func parseJSONDataset(contents []byte) ([]datasetRow, error) {
+	data, err := decodeJSON(string(contents))
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of objects, but the type is %s", jsonType(data))
+	}
+
+	rows := make([]datasetRow, 0, len(items))
+	for i, item := range items {
+		values, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected row %d to be an object, but its type is %s", i+1, jsonType(item))
+		}
+
+		rows = append(rows, datasetRow{number: i + 1, values: values})
+	}
+
+	return rows, nil
+}
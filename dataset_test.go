@@ -0,0 +1,83 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newUsersServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		user := map[string]interface{}{}
+		_ = json.Unmarshal(body, &user)
+
+		if r.Method == http.MethodGet {
+			user["name"] = strings.TrimPrefix(r.URL.Path, "/users/")
+		}
+
+		if user["name"] == "taken" {
+			w.WriteHeader(http.StatusConflict)
+		} else if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+
+		user["tenant"] = r.Header.Get("X-Tenant")
+		_ = json.NewEncoder(w).Encode(user)
+	}))
+}
+
+func TestApiContext_DatasetRequests(t *testing.T) {
+	ts := newUsersServer()
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithFixturesPath("testdata/datasets").
+		WithDebug(false)
+
+	assert.Error(t, ctx.TheResponseCodeForEachRowShouldBe(201))
+
+	assert.Nil(t, ctx.ISendRequestToWithBodyFromFileForEachRowIn("POST", "/users", "users.json"))
+	assert.Nil(t, ctx.TheResponseCodeForEachRowShouldBe(201))
+	assert.Nil(t, ctx.TheJSONPathForEachRowShouldHaveValue("$.name", "`##row.name`"))
+	assert.Nil(t, ctx.TheJSONPathForEachRowShouldHaveValue("$.age", "`##row.age`"))
+	assert.Nil(t, ctx.TheResponseCodeOfResponseShouldBe("row 2", 201))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldHaveValue("$.admin", "row 2", "true"))
+
+	assert.Nil(t, ctx.ISendRequestToWithBodyForEachRowIn("POST", "/users", "users.csv", &godog.DocString{Content: `{"name": "` + "`##row.name`" + `", "contact": {"email": "` + "`##row.email`" + `"}}`}))
+	assert.Nil(t, ctx.TheJSONPathForEachRowShouldHaveValue("$.contact.email", "`##row.email`"))
+	assert.EqualError(t, ctx.TheResponseCodeForEachRowShouldBe(201), `1 of 3 row(s) failed:
+ - row 3 {"email":"taken@example.com","name":"taken"}: expected status code to be 201, but actual is 409. Response body: {"contact":{"email":"taken@example.com"},"name":"taken","tenant":""}
+`)
+
+	assert.Nil(t, ctx.ISendRequestToForEachRowIn("GET", "/users/{row.name}", "users.csv"))
+	assert.Nil(t, ctx.TheJSONPathForEachRowShouldHaveValue("$.name", "`##row.name`"))
+	assert.Equal(t, "/users/taken", ctx.LastRequest().URL.Path)
+}
+
+func TestLoadDataset(t *testing.T) {
+	rows, err := loadDataset("testdata/datasets/users.json")
+	assert.Nil(t, err)
+	assert.Equal(t, []datasetRow{
+		{number: 1, values: map[string]interface{}{"name": "john", "age": json.Number("30"), "admin": false}},
+		{number: 2, values: map[string]interface{}{"name": "jane", "age": json.Number("25"), "admin": true}},
+	}, rows)
+
+	rows, err = loadDataset("testdata/datasets/users.csv")
+	assert.Nil(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, map[string]interface{}{"name": "jane", "email": "jane@example.com"}, rows[1].values)
+
+	_, err = loadDataset("testdata/datasets/invalid.json")
+	assert.EqualError(t, err, "invalid dataset testdata/datasets/invalid.json: expected row 2 to be an object, but its type is string")
+
+	_, err = loadDataset("testdata/datasets/users.yml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package apicontext
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// WithEnvScope Seeds the global scope with the process environment variables that start with the specified prefix.
+// The prefix is removed from the variable name. Ex: with prefix "APP_", "APP_API_KEY" is available as "##API_KEY".
+func (ctx *ApiContext) WithEnvScope(prefix string) *ApiContext {
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], prefix) {
+			continue
+		}
+
+		_ = ctx.scope.Set(GlobalScope, strings.TrimPrefix(parts[0], prefix), parts[1])
+	}
+
+	return ctx
+}
+
+// LoadEnvFile Seeds the global scope with the variables defined in one or more .env files.
+// When a variable is defined in more than one file, the last file wins.
+func (ctx *ApiContext) LoadEnvFile(paths ...string) error {
+	for _, path := range paths {
+		values, err := godotenv.Read(path)
+		if err != nil {
+			return fmt.Errorf("cannot read env file %s: %v", path, err)
+		}
+
+		for key, value := range values {
+			if err := ctx.scope.Set(GlobalScope, key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadProfile Seeds the global scope with the variables of a profile defined in a YAML file.
+// The file maps each profile name to its variables, which keep their YAML types:
+//
+//	staging:
+//	  API_KEY: abc
+//	  TENANT_ID: 10
+//	prod:
+//	  API_KEY: xyz
+//	  TENANT_ID: 20
+//
+// The caller selects the profile at run time, ex: from the API_CONTEXT_PROFILE environment variable.
+func (ctx *ApiContext) LoadProfile(path string, name string) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read profiles file: %v", err)
+	}
+
+	var profiles map[string]map[string]interface{}
+	if err := yaml.Unmarshal(contents, &profiles); err != nil {
+		return fmt.Errorf("invalid profiles file %s: %v", path, err)
+	}
+
+	values, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q is not defined in %s", name, path)
+	}
+
+	for key, value := range values {
+		if err := ctx.scope.Set(GlobalScope, key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
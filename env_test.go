@@ -0,0 +1,50 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_WithEnvScope(t *testing.T) {
+	assert.Nil(t, os.Setenv("GODOG_TEST_API_KEY", "secret"))
+	defer func() { _ = os.Unsetenv("GODOG_TEST_API_KEY") }()
+
+	ctx := setupTestContext().WithEnvScope("GODOG_TEST_")
+
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("API_KEY", "secret"))
+}
+
+func TestApiContext_LoadEnvFile(t *testing.T) {
+	ctx := setupTestContext()
+
+	assert.Nil(t, ctx.LoadEnvFile(filepath.Join("testdata", "env", "local.env")))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("API_KEY", "local-key"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("TENANT_ID", "acme"))
+	assert.Error(t, ctx.LoadEnvFile(filepath.Join("testdata", "env", "missing.env")))
+}
+
+func TestApiContext_LoadProfile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Api-Key", r.Header.Get("X-Api-Key"))
+		w.Header().Set("X-Tenant", r.URL.Query().Get("tenant"))
+	}))
+
+	defer ts.Close()
+
+	ctx := setupTestContext().WithBaseURL(ts.URL)
+
+	assert.Nil(t, ctx.LoadProfile(filepath.Join("testdata", "env", "profiles.yml"), "staging"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("TENANT_ID", "10"))
+	assert.Nil(t, ctx.ISetHeaderWithValue("X-Api-Key", "`##API_KEY`"))
+	assert.Nil(t, ctx.ISetQueryParamWithValue("tenant", "`##TENANT_ID`"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValue("X-Api-Key", "staging-key"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValue("X-Tenant", "10"))
+
+	assert.Error(t, ctx.LoadProfile(filepath.Join("testdata", "env", "profiles.yml"), "unknown"))
+}
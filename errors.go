@@ -0,0 +1,26 @@
+package apicontext
+
+import "errors"
+
+// Errors returned by the steps and the helpers for custom steps. They are wrapped with details about the failure,
+// so they should be checked with errors.Is.
+var (
+	// ErrNoResponse No request was sent in the current scenario, or the last request failed.
+	ErrNoResponse = errors.New("no response available, send a request first")
+	// ErrInvalidJSON The response body is not a valid JSON document.
+	ErrInvalidJSON = errors.New("the response body is not valid json")
+	// ErrPathNotFound The json path does not match any value of the response body.
+	ErrPathNotFound = errors.New("json path not found")
+	// ErrSchemaMismatch The response body does not match the json schema.
+	ErrSchemaMismatch = errors.New("the response does not match the json schema")
+)
+
+// response Returns the last response, or ErrNoResponse when there is none.
+// Every step checking the response goes through it, so a misordered step fails instead of panicking.
+func (ctx *ApiContext) response() (*ApiResponse, error) {
+	if ctx.lastResponse == nil {
+		return nil, ErrNoResponse
+	}
+
+	return ctx.lastResponse, nil
+}
@@ -0,0 +1,69 @@
+package apicontext
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_StepsWithoutResponse(t *testing.T) {
+	ctx := setupTestContext()
+
+	steps := map[string]error{
+		"TheResponseCodeShouldBe":          ctx.TheResponseCodeShouldBe(200),
+		"TheResponseShouldBeAValidJSON":    ctx.TheResponseShouldBeAValidJSON(),
+		"TheResponseShouldMatchJSON":       ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: "{}"}),
+		"TheResponseBodyShouldContain":     ctx.TheResponseBodyShouldContain("a"),
+		"TheResponseHeaderShouldHaveValue": ctx.TheResponseHeaderShouldHaveValue("X-Version", "1"),
+		"TheResponseHeaderShouldBePresent": ctx.TheResponseHeaderShouldBePresent("X-Version"),
+		"TheResponseContentTypeShouldBe":   ctx.TheResponseContentTypeShouldBe("application/json"),
+		"TheResponseShouldMatchJsonSchema": ctx.TheResponseShouldMatchJsonSchema("schema.json"),
+		"TheResponseShouldMatchSnapshot":   ctx.TheResponseShouldMatchSnapshot("missing"),
+		"TheJSONPathShouldHaveValue":       ctx.TheJSONPathShouldHaveValue("$.a", "a"),
+		"TheJQExpressionShouldEvaluateTo":  ctx.TheJQExpressionShouldEvaluateTo(".a", "a"),
+		"StoreResponseHeader":              ctx.StoreResponseHeader("X-Version", "version"),
+		"StoreJsonPathValue":               ctx.StoreJsonPathValue("$.a", "a"),
+	}
+
+	for name, err := range steps {
+		assert.True(t, errors.Is(err, ErrNoResponse), "%s: %v", name, err)
+	}
+}
+
+func TestApiContext_FailedRequestClearsResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ctx := setupTestContext().WithBaseURL(ts.URL).WithDebug(false)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	ts.Close()
+
+	assert.Error(t, ctx.ISendRequestTo("GET", "/"))
+	assert.True(t, errors.Is(ctx.TheResponseCodeShouldBe(200), ErrNoResponse))
+}
+
+func TestApiContext_TypedErrors(t *testing.T) {
+	body := `{"name": "godog", "age": -1}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().WithBaseURL(ts.URL).WithDebug(false)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.True(t, errors.Is(ctx.TheJSONPathShouldHaveValue("$.missing", "a"), ErrPathNotFound))
+	assert.True(t, errors.Is(ctx.TheResponseShouldMatchJsonSchema("person.json"), ErrSchemaMismatch))
+
+	_, err := ctx.JSONPath("$.name")
+	assert.Nil(t, err)
+
+	body = "not json"
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.True(t, errors.Is(ctx.TheResponseShouldBeAValidJSON(), ErrInvalidJSON))
+	assert.True(t, errors.Is(ctx.TheJSONPathShouldHaveValue("$.name", "godog"), ErrInvalidJSON))
+	assert.True(t, errors.Is(ctx.TheResponseShouldMatchJSON(&godog.DocString{Content: "{}"}), ErrInvalidJSON))
+}
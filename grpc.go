@@ -0,0 +1,425 @@
+package apicontext
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// WithGRPCDescriptorSets Sets the descriptor set files used to resolve gRPC methods, instead of the server reflection.
+// The files are generated with `protoc --include_imports --descriptor_set_out`.
+func (ctx *ApiContext) WithGRPCDescriptorSets(paths ...string) *ApiContext {
+	ctx.grpcDescriptorSets = append(ctx.grpcDescriptorSets, paths...)
+	return ctx
+}
+
+// ICallGRPCMethod Calls a unary gRPC method with an empty request message.
+func (ctx *ApiContext) ICallGRPCMethod(method string) error {
+	return ctx.callGRPCMethod(method, "{}")
+}
+
+// ICallGRPCMethodWithJSON Calls a unary gRPC method with a request message encoded as JSON.
+// The response message is converted to JSON, so the response steps can be used on it.
+func (ctx *ApiContext) ICallGRPCMethodWithJSON(method string, requestBody *godog.DocString) error {
+	body, err := ctx.replaceScopeVariablesInJSON(requestBody.Content)
+	if err != nil {
+		return err
+	}
+
+	return ctx.callGRPCMethod(method, body)
+}
+
+// TheGRPCStatusCodeShouldBe Check the status code of the last gRPC call, by name, ex: NOT_FOUND, or by number.
+func (ctx *ApiContext) TheGRPCStatusCodeShouldBe(expectedCode string) error {
+	if ctx.grpcStatus == nil {
+		return fmt.Errorf("%w: no grpc method was called", ErrNoResponse)
+	}
+
+	code, err := parseGRPCCode(expectedCode)
+	if err != nil {
+		return err
+	}
+
+	if ctx.grpcStatus.Code() != code {
+		return fmt.Errorf("expected grpc status code to be %s, but actual is %s: %s", code, ctx.grpcStatus.Code(), ctx.grpcStatus.Message())
+	}
+
+	return nil
+}
+
+// TheGRPCStatusMessageShouldBe Check the status message of the last gRPC call.
+func (ctx *ApiContext) TheGRPCStatusMessageShouldBe(expectedMessage string) error {
+	if ctx.grpcStatus == nil {
+		return fmt.Errorf("%w: no grpc method was called", ErrNoResponse)
+	}
+
+	expectedMessage, err := ctx.replaceScopeVariables(expectedMessage)
+	if err != nil {
+		return err
+	}
+
+	if ctx.grpcStatus.Message() != expectedMessage {
+		return fmt.Errorf("expected grpc status message to be %q, but actual is %q", expectedMessage, ctx.grpcStatus.Message())
+	}
+
+	return nil
+}
+
+// callGRPCMethod Calls a unary gRPC method on the base URL, and stores the response like an HTTP response.
+// The headers of the scenario are sent as metadata, and the response metadata are available as response headers.
+func (ctx *ApiContext) callGRPCMethod(method string, body string) error {
+	ctx.lastResponse = nil
+	ctx.grpcStatus = nil
+
+	serviceName, methodName, err := splitGRPCMethod(method)
+	if err != nil {
+		return err
+	}
+
+	baseURL, err := ctx.requestURL("")
+	if err != nil {
+		return err
+	}
+
+	target, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid grpc base URL %s: %v", baseURL, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(baseURL, "/"), serviceName, methodName), strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	md := metadata.MD{}
+	for name, values := range ctx.headers {
+		req.Header[name] = append([]string(nil), values...)
+		md.Append(strings.ToLower(name), values...)
+	}
+
+	ctx.logRequest(req)
+	ctx.lastRequest = req
+
+	conn, err := grpc.NewClient(target.Host, grpc.WithTransportCredentials(ctx.grpcCredentials(target.Scheme)))
+	if err != nil {
+		return fmt.Errorf("cannot connect to grpc server %s: %v", target.Host, err)
+	}
+
+	defer conn.Close()
+
+	callCtx := context.Background()
+	if ctx.client.Timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(callCtx, ctx.client.Timeout)
+		defer cancel()
+	}
+
+	methodDesc, err := ctx.findGRPCMethod(callCtx, conn, serviceName, methodName)
+	if err != nil {
+		return err
+	}
+
+	if methodDesc.IsStreamingClient() || methodDesc.IsStreamingServer() {
+		return fmt.Errorf("grpc method %s is a streaming method, only unary methods are supported", method)
+	}
+
+	request := dynamicpb.NewMessage(methodDesc.Input())
+	if err := protojson.Unmarshal([]byte(body), request); err != nil {
+		return fmt.Errorf("invalid request for grpc method %s: %v", method, err)
+	}
+
+	response := dynamicpb.NewMessage(methodDesc.Output())
+
+	var header, trailer metadata.MD
+	err = conn.Invoke(metadata.NewOutgoingContext(callCtx, md), fmt.Sprintf("/%s/%s", serviceName, methodName), request, response, grpc.Header(&header), grpc.Trailer(&trailer))
+
+	var responseBody []byte
+	if err == nil {
+		ctx.grpcStatus = status.New(codes.OK, "")
+
+		responseBody, err = protojson.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("cannot convert the grpc response to json: %v", err)
+		}
+	} else {
+		ctx.grpcStatus = status.Convert(err)
+
+		responseBody, err = protojson.Marshal(ctx.grpcStatus.Proto())
+		if err != nil {
+			// The details of the status cannot be converted when their types are unknown.
+			responseBody, _ = json.Marshal(map[string]interface{}{"code": ctx.grpcStatus.Code(), "message": ctx.grpcStatus.Message()})
+		}
+	}
+
+	resp := &http.Response{
+		StatusCode: grpcHTTPStatus(ctx.grpcStatus.Code()),
+		Header:     http.Header{},
+		Trailer:    http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(string(responseBody))),
+		Request:    req,
+	}
+	resp.Header.Set("Content-Type", "application/json")
+
+	for name, values := range header {
+		for _, value := range values {
+			resp.Header.Add(name, value)
+		}
+	}
+
+	for name, values := range trailer {
+		for _, value := range values {
+			resp.Trailer.Add(name, value)
+		}
+	}
+
+	if ctx.debug {
+		log.Printf("grpc status: %s %s\n%s\n", ctx.grpcStatus.Code(), ctx.grpcStatus.Message(), responseBody)
+	}
+
+	ctx.lastResponse = &ApiResponse{
+		StatusCode:  resp.StatusCode,
+		ResponseObj: resp,
+		Body:        string(responseBody),
+		RawBody:     responseBody,
+	}
+	ctx.history = append(ctx.history, exchange{request: req, response: ctx.lastResponse})
+
+	return nil
+}
+
+// grpcHTTPStatus Returns the HTTP status code equivalent to a gRPC status code, as defined in google/rpc/code.proto,
+// so the response code steps fail when a gRPC call fails.
+func grpcHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// grpcCredentials Returns the transport credentials for the scheme of the base URL, with the TLS settings of the HTTP client.
+func (ctx *ApiContext) grpcCredentials(scheme string) credentials.TransportCredentials {
+	if scheme != "https" {
+		return insecure.NewCredentials()
+	}
+
+	tlsConfig := &tls.Config{}
+	if transport, ok := ctx.client.Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+
+	return credentials.NewTLS(tlsConfig)
+}
+
+// findGRPCMethod Resolves a method from the descriptor sets, or from the server reflection when no descriptor set is configured.
+func (ctx *ApiContext) findGRPCMethod(callCtx context.Context, conn *grpc.ClientConn, serviceName string, methodName string) (protoreflect.MethodDescriptor, error) {
+	var files *protoregistry.Files
+	var err error
+
+	if len(ctx.grpcDescriptorSets) > 0 {
+		files, err = loadDescriptorSets(ctx.grpcDescriptorSets)
+	} else {
+		files, err = reflectGRPCService(callCtx, conn, serviceName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("grpc service %s not found: %v", serviceName, err)
+	}
+
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a grpc service", serviceName)
+	}
+
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("grpc method %s not found in service %s", methodName, serviceName)
+	}
+
+	return method, nil
+}
+
+// loadDescriptorSets Reads and merges descriptor set files.
+func loadDescriptorSets(paths []string) (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+
+	for _, path := range paths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read descriptor set: %v", err)
+		}
+
+		fileSet := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(contents, fileSet); err != nil {
+			return nil, fmt.Errorf("invalid descriptor set %s: %v", path, err)
+		}
+
+		set.File = append(set.File, fileSet.File...)
+	}
+
+	files, err := protodesc.NewFiles(dedupeFileDescriptors(set))
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor sets: %v", err)
+	}
+
+	return files, nil
+}
+
+// reflectGRPCService Fetches the descriptors of a service and of its dependencies with the server reflection.
+func reflectGRPCService(callCtx context.Context, conn *grpc.ClientConn, serviceName string) (*protoregistry.Files, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(callCtx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot use the grpc server reflection: %v", err)
+	}
+
+	defer func() { _ = stream.CloseSend() }()
+
+	set := &descriptorpb.FileDescriptorSet{}
+	known := map[string]bool{}
+
+	request := &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: serviceName},
+	}
+
+	for request != nil {
+		if err := stream.Send(request); err != nil {
+			return nil, fmt.Errorf("cannot use the grpc server reflection: %v", err)
+		}
+
+		response, err := stream.Recv()
+		if err != nil {
+			return nil, fmt.Errorf("cannot use the grpc server reflection: %v", err)
+		}
+
+		if errResponse := response.GetErrorResponse(); errResponse != nil {
+			return nil, fmt.Errorf("grpc service %s not found with the server reflection: %s", serviceName, errResponse.GetErrorMessage())
+		}
+
+		for _, contents := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(contents, file); err != nil {
+				return nil, fmt.Errorf("invalid descriptor from the grpc server reflection: %v", err)
+			}
+
+			if !known[file.GetName()] {
+				known[file.GetName()] = true
+				set.File = append(set.File, file)
+			}
+		}
+
+		// The server may omit the dependencies it already sent, or not send them at all.
+		request = nil
+		for _, file := range set.File {
+			for _, dependency := range file.GetDependency() {
+				if !known[dependency] {
+					known[dependency] = true
+					request = &reflectionpb.ServerReflectionRequest{
+						MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dependency},
+					}
+					break
+				}
+			}
+
+			if request != nil {
+				break
+			}
+		}
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors from the grpc server reflection: %v", err)
+	}
+
+	return files, nil
+}
+
+// dedupeFileDescriptors Removes the files included in several descriptor sets.
+func dedupeFileDescriptors(set *descriptorpb.FileDescriptorSet) *descriptorpb.FileDescriptorSet {
+	known := map[string]bool{}
+	deduped := &descriptorpb.FileDescriptorSet{}
+
+	for _, file := range set.File {
+		if !known[file.GetName()] {
+			known[file.GetName()] = true
+			deduped.File = append(deduped.File, file)
+		}
+	}
+
+	return deduped
+}
+
+// splitGRPCMethod Splits a full method name, ex: "pkg.Service/Method", in service and method names.
+func splitGRPCMethod(method string) (string, string, error) {
+	parts := strings.Split(strings.TrimPrefix(method, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid grpc method %q, expected a name like pkg.Service/Method", method)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// parseGRPCCode Parses a gRPC status code from its name, ex: NOT_FOUND or NotFound, or from its number.
+func parseGRPCCode(value string) (codes.Code, error) {
+	if number, err := strconv.ParseUint(value, 10, 32); err == nil && number <= uint64(codes.Unauthenticated) {
+		return codes.Code(number), nil
+	}
+
+	name := strings.ReplaceAll(value, "_", "")
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if strings.EqualFold(code.String(), name) || (code == codes.Canceled && strings.EqualFold(name, "cancelled")) {
+			return code, nil
+		}
+	}
+
+	return codes.Unknown, fmt.Errorf("invalid grpc status code %q", value)
+}
@@ -0,0 +1,122 @@
+package apicontext
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// newGRPCServer Starts a gRPC server with the health service, which echoes the "x-tenant" metadata in the response headers.
+func newGRPCServer(t *testing.T, withReflection bool) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		_ = grpc.SetHeader(ctx, metadata.MD{"x-tenant": md.Get("x-tenant")})
+		return handler(ctx, req)
+	}))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if withReflection {
+		reflection.Register(server)
+	}
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return "http://" + listener.Addr().String()
+}
+
+func TestApiContext_GRPC(t *testing.T) {
+	ctx := setupTestContext().
+		WithBaseURL(newGRPCServer(t, true)).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("X-Tenant", "acme"))
+	assert.Nil(t, ctx.ICallGRPCMethodWithJSON("grpc.health.v1.Health/Check", &godog.DocString{Content: `{"service": "orders"}`}))
+	assert.Nil(t, ctx.TheGRPCStatusCodeShouldBe("OK"))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(200))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.status", "SERVING"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValue("X-Tenant", "acme"))
+	assert.Equal(t, "/grpc.health.v1.Health/Check", ctx.LastRequest().URL.Path)
+
+	assert.Nil(t, ctx.ICallGRPCMethodWithJSON("grpc.health.v1.Health/Check", &godog.DocString{Content: `{"service": "payments"}`}))
+	assert.Nil(t, ctx.TheGRPCStatusCodeShouldBe("NOT_FOUND"))
+	assert.Nil(t, ctx.TheGRPCStatusCodeShouldBe("5"))
+	assert.Nil(t, ctx.TheResponseCodeShouldBe(404))
+	assert.Error(t, ctx.TheResponseCodeShouldBe(200))
+	assert.Nil(t, ctx.TheGRPCStatusMessageShouldBe("unknown service"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.code", "5"))
+	assert.EqualError(t, ctx.TheGRPCStatusCodeShouldBe("OK"), "expected grpc status code to be OK, but actual is NotFound: unknown service")
+
+	assert.Error(t, ctx.ICallGRPCMethodWithJSON("grpc.health.v1.Health/Check", &godog.DocString{Content: `{"unknown": true}`}))
+	assert.EqualError(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Ping"), "grpc method Ping not found in service grpc.health.v1.Health")
+	assert.Error(t, ctx.ICallGRPCMethod("orders.Orders/Get"))
+	assert.Error(t, ctx.ICallGRPCMethod("Check"))
+	assert.EqualError(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Watch"), "grpc method grpc.health.v1.Health/Watch is a streaming method, only unary methods are supported")
+}
+
+func TestApiContext_GRPCDescriptorSets(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto)}}
+	contents, err := proto.Marshal(set)
+	assert.Nil(t, err)
+
+	path := filepath.Join(t.TempDir(), "health.pb")
+	assert.Nil(t, ioutil.WriteFile(path, contents, 0644))
+
+	ctx := setupTestContext().
+		WithBaseURL(newGRPCServer(t, false)).
+		WithDebug(false)
+
+	assert.Error(t, ctx.ICallGRPCMethod("grpc.health.v1.Health/Check"))
+
+	ctx.WithGRPCDescriptorSets(path)
+	assert.Nil(t, ctx.ICallGRPCMethodWithJSON("grpc.health.v1.Health/Check", &godog.DocString{Content: `{"service": "orders"}`}))
+	assert.Nil(t, ctx.TheGRPCStatusCodeShouldBe("OK"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.status", "SERVING"))
+}
+
+func TestGRPCHTTPStatus(t *testing.T) {
+	assert.Equal(t, 200, grpcHTTPStatus(codes.OK))
+	assert.Equal(t, 404, grpcHTTPStatus(codes.NotFound))
+	assert.Equal(t, 503, grpcHTTPStatus(codes.Unavailable))
+	assert.Equal(t, 500, grpcHTTPStatus(codes.DataLoss))
+}
+
+func TestParseGRPCCode(t *testing.T) {
+	for value, expected := range map[string]codes.Code{
+		"OK":                codes.OK,
+		"NOT_FOUND":         codes.NotFound,
+		"NotFound":          codes.NotFound,
+		"deadline_exceeded": codes.DeadlineExceeded,
+		"CANCELLED":         codes.Canceled,
+		"16":                codes.Unauthenticated,
+	} {
+		code, err := parseGRPCCode(value)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, code, value)
+	}
+
+	_, err := parseGRPCCode("17")
+	assert.Error(t, err)
+	_, err = parseGRPCCode("MISSING")
+	assert.Error(t, err)
+}
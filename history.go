@@ -0,0 +1,166 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cucumber/godog"
+)
+
+// exchange A request sent in the current scenario and its response.
+type exchange struct {
+	name     string
+	request  *http.Request
+	response *ApiResponse
+}
+
+// ISendRequestToAs Sends a request and names its response, so later steps can refer to it.
+func (ctx *ApiContext) ISendRequestToAs(method, uri string, name string) error {
+	if err := validateResponseName(name); err != nil {
+		return err
+	}
+
+	if err := ctx.ISendRequestTo(method, uri); err != nil {
+		return err
+	}
+
+	ctx.history[len(ctx.history)-1].name = name
+
+	return nil
+}
+
+// ISendRequestToAsWithBody Sends a request with json body and names its response, so later steps can refer to it.
+func (ctx *ApiContext) ISendRequestToAsWithBody(method, uri string, name string, requestBody *godog.DocString) error {
+	if err := validateResponseName(name); err != nil {
+		return err
+	}
+
+	if err := ctx.ISendRequestToWithBody(method, uri, requestBody); err != nil {
+		return err
+	}
+
+	ctx.history[len(ctx.history)-1].name = name
+
+	return nil
+}
+
+// Response Returns a response of the current scenario, by the name given when sending the request
+// or by its position, starting at 1 for the first request of the scenario.
+func (ctx *ApiContext) Response(ref string) (*ApiResponse, error) {
+	e, err := ctx.findExchange(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.response, nil
+}
+
+// Request Returns a request of the current scenario, by the name given when sending it or by its position.
+func (ctx *ApiContext) Request(ref string) (*http.Request, error) {
+	e, err := ctx.findExchange(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.request, nil
+}
+
+// findExchange Looks up a request and its response by name or by position in the history of the scenario.
+func (ctx *ApiContext) findExchange(ref string) (*exchange, error) {
+	for i := len(ctx.history) - 1; i >= 0; i-- {
+		if ctx.history[i].name == ref {
+			return &ctx.history[i], nil
+		}
+	}
+
+	if index, err := strconv.Atoi(ref); err == nil && index >= 1 && index <= len(ctx.history) {
+		return &ctx.history[index-1], nil
+	}
+
+	return nil, fmt.Errorf("%w: response %q not found in the %d response(s) of the scenario", ErrNoResponse, ref, len(ctx.history))
+}
+
+// TheResponseCodeOfResponseShouldBe Check the http status code of a named or indexed response.
+func (ctx *ApiContext) TheResponseCodeOfResponseShouldBe(ref string, statusCode int) error {
+	response, err := ctx.Response(ref)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != response.StatusCode {
+		return fmt.Errorf("expected status code of response %s to be %d, but actual is %d.\n Response body: %s", ref, statusCode, response.StatusCode, response.Body)
+	}
+
+	return nil
+}
+
+// TheJSONPathOfResponseShouldHaveValue Validates the value at the specified json path of a named or indexed response.
+func (ctx *ApiContext) TheJSONPathOfResponseShouldHaveValue(pathExpr string, ref string, expectedValue string) error {
+	response, err := ctx.Response(ref)
+	if err != nil {
+		return err
+	}
+
+	return ctx.jsonPathShouldHaveValue(response, pathExpr, expectedValue)
+}
+
+// TheJSONPathOfResponseShouldCompareTo Compares the value at the specified json path in two responses.
+// Ex: the "$.count" of response "after" should be greater than in response "before".
+// "greater than" and "less than" require numbers, while "the same as" and "different from" accept any JSON value.
+func (ctx *ApiContext) TheJSONPathOfResponseShouldCompareTo(pathExpr string, ref string, comparison string, otherRef string) error {
+	actual, err := ctx.jsonPathOfResponse(pathExpr, ref)
+	if err != nil {
+		return err
+	}
+
+	other, err := ctx.jsonPathOfResponse(pathExpr, otherRef)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	switch comparison {
+	case "the same as":
+		ok = ctx.valuesEqual(actual, other)
+	case "different from":
+		ok = !ctx.valuesEqual(actual, other)
+	default:
+		a, isNumber := toRat(actual)
+		b, isOtherNumber := toRat(other)
+		if !isNumber || !isOtherNumber {
+			return fmt.Errorf("cannot compare json path %s: %s and %s must be numbers", pathExpr, jsonLiteral(actual), jsonLiteral(other))
+		}
+
+		if comparison == "greater than" {
+			ok = a.Cmp(b) > 0
+		} else {
+			ok = a.Cmp(b) < 0
+		}
+	}
+
+	if !ok {
+		return fmt.Errorf("expected json path %s of response %s (%s) to be %s in response %s (%s)", pathExpr, ref, jsonLiteral(actual), comparison, otherRef, jsonLiteral(other))
+	}
+
+	return nil
+}
+
+// jsonPathOfResponse Returns the value at the specified json path of a named or indexed response.
+func (ctx *ApiContext) jsonPathOfResponse(pathExpr string, ref string) (interface{}, error) {
+	response, err := ctx.Response(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.JSONPath(pathExpr)
+}
+
+// validateResponseName Checks that a response name cannot be confused with a position.
+func validateResponseName(name string) error {
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("invalid response name %q: numbers are reserved to refer to responses by position", name)
+	}
+
+	return nil
+}
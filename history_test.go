@@ -0,0 +1,64 @@
+package apicontext
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_ResponseHistory(t *testing.T) {
+	count := 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			count++
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = fmt.Fprintf(w, `{"count": %d, "name": "cart"}`, count)
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISendRequestToAs("GET", "/cart", "before"))
+	assert.Nil(t, ctx.ISendRequestToAsWithBody("POST", "/cart/items", "added", &godog.DocString{Content: `{"id": 1}`}))
+	assert.Nil(t, ctx.ISendRequestToAs("GET", "/cart", "after"))
+
+	assert.Nil(t, ctx.TheResponseCodeOfResponseShouldBe("added", 201))
+	assert.Nil(t, ctx.TheResponseCodeOfResponseShouldBe("1", 200))
+	assert.Error(t, ctx.TheResponseCodeOfResponseShouldBe("before", 201))
+
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldHaveValue("$.count", "before", "1"))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldHaveValue("$.count", "3", "2"))
+	assert.Nil(t, ctx.TheJSONPathShouldHaveValue("$.count", "2"))
+
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldCompareTo("$.count", "after", "greater than", "before"))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldCompareTo("$.count", "before", "less than", "after"))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldCompareTo("$.name", "after", "the same as", "before"))
+	assert.Nil(t, ctx.TheJSONPathOfResponseShouldCompareTo("$.count", "after", "different from", "before"))
+	assert.EqualError(t, ctx.TheJSONPathOfResponseShouldCompareTo("$.count", "before", "greater than", "after"),
+		"expected json path $.count of response before (1) to be greater than in response after (2)")
+	assert.EqualError(t, ctx.TheJSONPathOfResponseShouldCompareTo("$.name", "before", "greater than", "after"),
+		`cannot compare json path $.name: "cart" and "cart" must be numbers`)
+
+	request, err := ctx.Request("added")
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPost, request.Method)
+
+	_, err = ctx.Response("missing")
+	assert.True(t, errors.Is(err, ErrNoResponse))
+	_, err = ctx.Response("4")
+	assert.True(t, errors.Is(err, ErrNoResponse))
+
+	assert.Error(t, ctx.ISendRequestToAs("GET", "/cart", "2"))
+
+	ctx.reset(&godog.Scenario{Uri: "test.feature"})
+	_, err = ctx.Response("before")
+	assert.True(t, errors.Is(err, ErrNoResponse))
+}
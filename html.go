@@ -0,0 +1,184 @@
+package apicontext
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// TheHTMLElementShouldBePresent Checks that the HTML response has an element matching the specified CSS selector.
+func (ctx *ApiContext) TheHTMLElementShouldBePresent(selector string) error {
+	selection, err := ctx.htmlSelection(selector)
+	if err != nil {
+		return err
+	}
+
+	if selection.Length() == 0 {
+		return fmt.Errorf("expected html element %s to be present", selector)
+	}
+
+	return nil
+}
+
+// TheHTMLElementShouldNotBePresent Checks that the HTML response has no element matching the specified CSS selector.
+func (ctx *ApiContext) TheHTMLElementShouldNotBePresent(selector string) error {
+	selection, err := ctx.htmlSelection(selector)
+	if err != nil {
+		return err
+	}
+
+	if selection.Length() != 0 {
+		return fmt.Errorf("expected html element %s not to be present, but %d element(s) match", selector, selection.Length())
+	}
+
+	return nil
+}
+
+// TheHTMLElementShouldHaveCount Checks the number of elements matching the specified CSS selector.
+func (ctx *ApiContext) TheHTMLElementShouldHaveCount(selector string, expectedCount int) error {
+	selection, err := ctx.htmlSelection(selector)
+	if err != nil {
+		return err
+	}
+
+	if selection.Length() != expectedCount {
+		return fmt.Errorf("expected html element %s to have count %d, but actual is %d", selector, expectedCount, selection.Length())
+	}
+
+	return nil
+}
+
+// TheHTMLElementShouldHaveText Checks the text of the first element matching the specified CSS selector.
+// The whitespaces of the text are collapsed, like in a browser.
+func (ctx *ApiContext) TheHTMLElementShouldHaveText(selector string, expectedText string) error {
+	expectedText, err := ctx.replaceScopeVariables(expectedText)
+	if err != nil {
+		return err
+	}
+
+	actualText, err := ctx.htmlText(selector)
+	if err != nil {
+		return err
+	}
+
+	if actualText != expectedText {
+		return fmt.Errorf("expected html element %s to have text %q, but actual is %q", selector, expectedText, actualText)
+	}
+
+	return nil
+}
+
+// TheHTMLElementTextShouldMatch Checks that the text of the first element matching the specified CSS selector matches a pattern.
+func (ctx *ApiContext) TheHTMLElementTextShouldMatch(selector string, pattern string) error {
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	actualText, err := ctx.htmlText(selector)
+	if err != nil {
+		return err
+	}
+
+	if !r.MatchString(actualText) {
+		return fmt.Errorf("expected html element %s text to match %s, but actual is %q", selector, pattern, actualText)
+	}
+
+	return nil
+}
+
+// TheHTMLElementShouldHaveAttribute Checks an attribute of the first element matching the specified CSS selector.
+func (ctx *ApiContext) TheHTMLElementShouldHaveAttribute(selector string, name string, expectedValue string) error {
+	expectedValue, err := ctx.replaceScopeVariables(expectedValue)
+	if err != nil {
+		return err
+	}
+
+	actualValue, err := ctx.htmlAttribute(selector, name)
+	if err != nil {
+		return err
+	}
+
+	if actualValue != expectedValue {
+		return fmt.Errorf("expected attribute %s of html element %s to have value %q, but actual is %q", name, selector, expectedValue, actualValue)
+	}
+
+	return nil
+}
+
+// StoreHTMLElementTextIn Stores the text of the first element matching the specified CSS selector in the specified scope level.
+func (ctx *ApiContext) StoreHTMLElementTextIn(selector string, scopeKeyName string, level string) error {
+	text, err := ctx.htmlText(selector)
+	if err != nil {
+		return err
+	}
+
+	return ctx.scope.Set(ScopeLevel(level), scopeKeyName, text)
+}
+
+// StoreHTMLElementAttributeIn Stores an attribute of the first element matching the specified CSS selector in the specified scope level.
+// Ex: the CSRF token of a form, to send it with the next form body.
+func (ctx *ApiContext) StoreHTMLElementAttributeIn(name string, selector string, scopeKeyName string, level string) error {
+	value, err := ctx.htmlAttribute(selector, name)
+	if err != nil {
+		return err
+	}
+
+	return ctx.scope.Set(ScopeLevel(level), scopeKeyName, value)
+}
+
+// htmlSelection Returns the elements of the HTML response matching the specified CSS selector.
+func (ctx *ApiContext) htmlSelection(selector string) (*goquery.Selection, error) {
+	response, err := ctx.response()
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid css selector %s: %v", selector, err)
+	}
+
+	doc, err := response.HTML()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.FindMatcher(matcher), nil
+}
+
+// htmlText Returns the text of the first element matching the specified CSS selector, with collapsed whitespaces.
+func (ctx *ApiContext) htmlText(selector string) (string, error) {
+	selection, err := ctx.htmlSelection(selector)
+	if err != nil {
+		return "", err
+	}
+
+	if selection.Length() == 0 {
+		return "", fmt.Errorf("html element %s not found", selector)
+	}
+
+	return strings.Join(strings.Fields(selection.First().Text()), " "), nil
+}
+
+// htmlAttribute Returns an attribute of the first element matching the specified CSS selector.
+func (ctx *ApiContext) htmlAttribute(selector string, name string) (string, error) {
+	selection, err := ctx.htmlSelection(selector)
+	if err != nil {
+		return "", err
+	}
+
+	if selection.Length() == 0 {
+		return "", fmt.Errorf("html element %s not found", selector)
+	}
+
+	value, ok := selection.First().Attr(name)
+	if !ok {
+		return "", fmt.Errorf("html element %s has no attribute %s", selector, name)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,98 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const loginPage = `<!DOCTYPE html>
+<html>
+<head><title>Sign in</title></head>
+<body>
+  <h1 class="title">
+    Welcome   back
+  </h1>
+  <form id="login" action="/login" method="post">
+    <input type="hidden" name="csrf" value="token-123">
+    <input type="text" name="user">
+  </form>
+  <ul class="links">
+    <li><a href="/help">Help</a></li>
+    <li><a href="/signup">Sign up</a></li>
+  </ul>
+</body>
+</html>`
+
+func newHTMLServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if r.FormValue("csrf") != "token-123" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(loginPage))
+	}))
+}
+
+func TestApiContext_HTMLSteps(t *testing.T) {
+	ts := newHTMLServer()
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Error(t, ctx.TheHTMLElementShouldBePresent("h1"))
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/login"))
+
+	assert.Nil(t, ctx.TheHTMLElementShouldBePresent("form#login"))
+	assert.Nil(t, ctx.TheHTMLElementShouldNotBePresent(".error"))
+	assert.EqualError(t, ctx.TheHTMLElementShouldNotBePresent("input"), "expected html element input not to be present, but 2 element(s) match")
+	assert.Nil(t, ctx.TheHTMLElementShouldHaveCount("ul.links > li", 2))
+	assert.EqualError(t, ctx.TheHTMLElementShouldHaveCount("a", 3), "expected html element a to have count 3, but actual is 2")
+
+	assert.Nil(t, ctx.TheHTMLElementShouldHaveText("h1.title", "Welcome back"))
+	assert.Nil(t, ctx.TheHTMLElementShouldHaveText("a", "Help"))
+	assert.Nil(t, ctx.TheHTMLElementTextShouldMatch("title", "^Sign"))
+	assert.EqualError(t, ctx.TheHTMLElementShouldHaveText("h1", "Welcome"), `expected html element h1 to have text "Welcome", but actual is "Welcome back"`)
+
+	assert.Nil(t, ctx.TheHTMLElementShouldHaveAttribute("a[href$=signup]", "href", "/signup"))
+	assert.Nil(t, ctx.TheHTMLElementShouldHaveAttribute("input[name='csrf']", "value", "token-123"))
+	assert.EqualError(t, ctx.TheHTMLElementShouldHaveAttribute("h1", "id", "main"), "html element h1 has no attribute id")
+	assert.EqualError(t, ctx.TheHTMLElementShouldHaveText("table", ""), "html element table not found")
+	assert.Error(t, ctx.TheHTMLElementShouldBePresent("ul >"))
+
+	assert.Nil(t, ctx.StoreHTMLElementTextIn("h1", "title", "scenario"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("title", "Welcome back"))
+}
+
+const csrfFeature = `Feature: forms
+  Scenario: log in with the csrf token of the form
+    When I send "GET" request to "/login"
+    And I store the attribute "value" of html element "form#login input[name=csrf]" as "csrf" in scenario scope
+    And I send "POST" request to "/login" with form body::
+      | csrf | ` + "`##csrf`" + ` | text |
+      | user | john     | text |
+    Then The response code should be 204
+`
+
+func TestApiContext_HTMLFormToken(t *testing.T) {
+	ts := newHTMLServer()
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	status, _ := runTestSuite(t, ctx, csrfFeature)
+	assert.Equal(t, 0, status)
+}
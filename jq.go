@@ -0,0 +1,96 @@
+package apicontext
+
+import (
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// TheJQExpressionShouldEvaluateTo Validates the result of a jq expression evaluated against the response body.
+// String results are compared as text, while other types are compared with the expected value parsed as JSON.
+func (ctx *ApiContext) TheJQExpressionShouldEvaluateTo(expr string, expectedValue string) error {
+	expectedValue, err := ctx.replaceScopeVariables(expectedValue)
+	if err != nil {
+		return err
+	}
+
+	actualValue, err := ctx.evaluateJQ(expr)
+	if err != nil {
+		return err
+	}
+
+	if _, isString := actualValue.(string); !isString {
+		if expectedParsedValue, err := decodeJSON(expectedValue); err == nil && ctx.valuesEqual(actualValue, expectedParsedValue) {
+			return nil
+		}
+	}
+
+	if stringifyJSON(actualValue) != expectedValue {
+		return fmt.Errorf("expected jq expression %s to evaluate to %s, but it is %s", expr, expectedValue, stringifyJSON(actualValue))
+	}
+
+	return nil
+}
+
+// StoreJQResult Store the result of a jq expression to scenario scope.
+func (ctx *ApiContext) StoreJQResult(expr string, scopeKeyName string) error {
+	return ctx.StoreJQResultIn(expr, scopeKeyName, string(ScenarioScope))
+}
+
+// StoreJQResultIn Store the result of a jq expression to the specified scope level.
+func (ctx *ApiContext) StoreJQResultIn(expr string, scopeKeyName string, level string) error {
+	value, err := ctx.evaluateJQ(expr)
+	if err != nil {
+		return err
+	}
+
+	return ctx.scope.Set(ScopeLevel(level), scopeKeyName, value)
+}
+
+// evaluateJQ Runs a jq expression against the response body.
+// Scope placeholders in the expression are replaced by JSON literals before parsing it.
+// An expression producing several values, like ".items[].id", returns them as an array.
+func (ctx *ApiContext) evaluateJQ(expr string) (interface{}, error) {
+	expanded, err := ctx.replaceScopeVariablesInJSON(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := gojq.Parse(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq expression %s: %v", expr, err)
+	}
+
+	jsonData, err := ctx.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []interface{}
+	iter := query.Run(jsonData)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+
+		if err, isError := value.(error); isError {
+			return nil, fmt.Errorf("cannot evaluate jq expression %s: %v", expr, err)
+		}
+
+		results = append(results, value)
+	}
+
+	var result interface{}
+	switch len(results) {
+	case 0:
+		return nil, fmt.Errorf("the jq expression %s did not produce any value", expr)
+	case 1:
+		result = results[0]
+	default:
+		result = results
+	}
+
+	// jq produces int, float64 and *big.Int numbers, which are converted to json.Number like the numbers of the response body.
+	return normalizeJSON(result)
+}
@@ -0,0 +1,70 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_TheJQExpressionShouldEvaluateTo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items": [{"name": "a", "status": "active", "price": 10}, {"name": "b", "status": "deleted", "price": 5.5}, {"name": "c", "status": "active", "price": 2}]}`))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheJQExpressionShouldEvaluateTo(".items | length", "3"))
+	assert.Nil(t, ctx.TheJQExpressionShouldEvaluateTo(".items[0].name", "a"))
+	assert.Nil(t, ctx.TheJQExpressionShouldEvaluateTo(`[.items[] | select(.status == "active") | .name]`, `["a","c"]`))
+	assert.Nil(t, ctx.TheJQExpressionShouldEvaluateTo(".items | map(.price) | add", "17.5"))
+	assert.Nil(t, ctx.TheJQExpressionShouldEvaluateTo(".items[0].price * 2 + 1", "21"))
+	assert.Nil(t, ctx.TheJQExpressionShouldEvaluateTo(".items[].name", `["a","b","c"]`))
+	assert.Nil(t, ctx.TheJQExpressionShouldEvaluateTo(".missing", "null"))
+
+	assert.Nil(t, ctx.StoreScopeData("status", "deleted"))
+	assert.Nil(t, ctx.TheJQExpressionShouldEvaluateTo(".items[] | select(.status == `##status`) | .name", "b"))
+
+	err := ctx.TheJQExpressionShouldEvaluateTo(".items | length", "2")
+	assert.EqualError(t, err, "expected jq expression .items | length to evaluate to 2, but it is 3")
+
+	err = ctx.TheJQExpressionShouldEvaluateTo(".items[] | select(.price > 100)", "null")
+	assert.EqualError(t, err, "the jq expression .items[] | select(.price > 100) did not produce any value")
+
+	err = ctx.TheJQExpressionShouldEvaluateTo(".items | map(", "null")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid jq expression")
+
+	err = ctx.TheJQExpressionShouldEvaluateTo(".items[0].name + 1", "null")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot evaluate jq expression")
+}
+
+func TestApiContext_StoreJQResult(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items": [{"id": 1, "tags": ["x"]}, {"id": 2, "tags": ["y", "z"]}]}`))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.StoreJQResult("[.items[].id]", "ids"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("ids", "[1,2]"))
+	assert.Nil(t, ctx.StoreJQResultIn(".items | map(.tags | length) | add", "tags", string(FeatureScope)))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("tags", "3"))
+
+	value, ok := ctx.scope.levels[FeatureScope]["tags"]
+	assert.True(t, ok)
+	assert.Equal(t, json.Number("3"), value)
+}
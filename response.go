@@ -0,0 +1,119 @@
+package apicontext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/PaesslerAG/gval"
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// jsonPathLanguage The JSONPath language, with equality operators that compare json.Number values as numbers.
+// The operators are declared first, as gval keeps the implementation of the first language defining an operator.
+var jsonPathLanguage = gval.NewLanguage(
+	gval.InfixOperator("==", func(a, b interface{}) (interface{}, error) {
+		return jsonPathEqual(a, b), nil
+	}),
+	gval.InfixOperator("!=", func(a, b interface{}) (interface{}, error) {
+		return !jsonPathEqual(a, b), nil
+	}),
+	jsonpath.Language(),
+)
+
+// ApiResponse Struct that wraps an API response.
+// It contains common accessed fields like Status Code and the Payload as well as access to the raw http.Response object
+// Body is decoded according to the Content-Encoding of the response, while RawBody holds the bytes as received.
+type ApiResponse struct {
+	StatusCode  int
+	Body        string
+	RawBody     []byte
+	ResponseObj *http.Response
+
+	decoded   bool
+	jsonData  interface{}
+	jsonErr   error
+	htmlDoc   *goquery.Document
+	decodeErr error
+}
+
+// JSON Returns the decoded JSON body of the response.
+// The body is decoded on the first call and cached for the next ones.
+// Numbers are decoded as json.Number, so large integers keep their precision.
+func (r *ApiResponse) JSON() (interface{}, error) {
+	if !r.decoded {
+		r.jsonData, r.jsonErr = decodeJSON(r.Body)
+		if r.jsonErr != nil {
+			r.jsonErr = fmt.Errorf("%w: %v", ErrInvalidJSON, r.jsonErr)
+		}
+		r.decoded = true
+	}
+
+	return r.jsonData, r.jsonErr
+}
+
+// JSONPath Returns the value at the specified json path of the decoded JSON body.
+func (r *ApiResponse) JSONPath(pathExpr string) (interface{}, error) {
+	data, err := r.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluateJSONPath(data, pathExpr)
+}
+
+// HTML Returns the parsed HTML body of the response.
+// The body is parsed on the first call and cached for the next ones.
+func (r *ApiResponse) HTML() (*goquery.Document, error) {
+	if r.htmlDoc == nil {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(r.Body))
+		if err != nil {
+			return nil, fmt.Errorf("the response body is not valid html: %v", err)
+		}
+		r.htmlDoc = doc
+	}
+
+	return r.htmlDoc, nil
+}
+
+// evaluateJSONPath Returns the value at the specified json path of a decoded JSON document.
+func evaluateJSONPath(data interface{}, pathExpr string) (interface{}, error) {
+	eval, err := jsonPathLanguage.NewEvaluable(pathExpr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json path %s: %v", pathExpr, err)
+	}
+
+	value, err := eval(context.Background(), data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrPathNotFound, pathExpr, err)
+	}
+
+	return value, nil
+}
+
+// decodeJSON Decodes a JSON document, keeping numbers as json.Number.
+func decodeJSON(body string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewBufferString(body))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+
+	return data, nil
+}
+
+// jsonPathEqual Compares two values of a json path filter. Numbers are compared by value, whatever their type.
+func jsonPathEqual(a, b interface{}) bool {
+	return valuesEqual(a, b, 0)
+}
@@ -0,0 +1,50 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiResponse_JSON(t *testing.T) {
+	response := &ApiResponse{Body: `{"id": 12345678901234567890, "price": 3.50, "tags": ["a"]}`}
+
+	data, err := response.JSON()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":    json.Number("12345678901234567890"),
+		"price": json.Number("3.50"),
+		"tags":  []interface{}{"a"},
+	}, data)
+
+	response.Body = `{}`
+	cached, err := response.JSON()
+	assert.Nil(t, err)
+	assert.Equal(t, data, cached)
+}
+
+func TestApiResponse_JSONInvalid(t *testing.T) {
+	_, err := (&ApiResponse{Body: `{"id": 1`}).JSON()
+	assert.True(t, errors.Is(err, ErrInvalidJSON))
+
+	_, err = (&ApiResponse{Body: `{"id": 1} {"id": 2}`}).JSON()
+	assert.EqualError(t, err, "the response body is not valid json: invalid character after top-level value")
+}
+
+func TestApiResponse_JSONPath(t *testing.T) {
+	response := &ApiResponse{Body: `{"items": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}], "total": 9007199254740993}`}
+
+	value, err := response.JSONPath("$.total")
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("9007199254740993"), value)
+
+	value, err = response.JSONPath("$.items[?(@.id == 2)].name")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"b"}, value)
+
+	value, err = response.JSONPath("$.items[?(@.id != 2)].name")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a"}, value)
+}
@@ -0,0 +1,285 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScopeLevel Defines the lifetime of a scope variable.
+type ScopeLevel string
+
+const (
+	// ScenarioScope variables are cleared before each scenario.
+	ScenarioScope ScopeLevel = "scenario"
+	// FeatureScope variables are cleared when a scenario from a different feature starts.
+	FeatureScope ScopeLevel = "feature"
+	// GlobalScope variables live for the whole test suite.
+	GlobalScope ScopeLevel = "global"
+)
+
+// scopeLookupOrder The order in which the scope levels are searched. The most specific level wins.
+var scopeLookupOrder = []ScopeLevel{ScenarioScope, FeatureScope, GlobalScope}
+
+// Scope Holds the scope variables of each level.
+// Values are kept with their JSON type: string, number (json.Number), bool, nil, object (map[string]interface{}) or array ([]interface{}).
+type Scope struct {
+	levels map[ScopeLevel]map[string]interface{}
+}
+
+// newScope Creates an empty scope.
+func newScope() *Scope {
+	s := &Scope{levels: make(map[ScopeLevel]map[string]interface{})}
+	for _, level := range scopeLookupOrder {
+		s.levels[level] = make(map[string]interface{})
+	}
+
+	return s
+}
+
+// Set Stores a variable in the specified level.
+// Values that are not JSON types, like structs, are converted through their JSON representation.
+func (s *Scope) Set(level ScopeLevel, key string, value interface{}) error {
+	values, ok := s.levels[level]
+	if !ok {
+		return fmt.Errorf("invalid scope level %q. valid levels are: %v", level, scopeLookupOrder)
+	}
+
+	normalized, err := normalizeJSON(value)
+	if err != nil {
+		return fmt.Errorf("cannot store scope variable %q: %v", key, err)
+	}
+
+	values[key] = normalized
+
+	return nil
+}
+
+// find Looks up a variable, starting from the scenario scope and falling back to the feature and global scopes.
+func (s *Scope) find(key string) (interface{}, bool) {
+	for _, level := range scopeLookupOrder {
+		if value, ok := s.levels[level][key]; ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Get Resolves a scope variable, starting from the scenario scope and falling back to the feature and global scopes.
+// It supports dotted access to the fields of objects and arrays.
+// Ex: "user.address.city" resolves the "address.city" field of the "user" variable.
+// String variables holding a JSON document are decoded before being navigated.
+func (s *Scope) Get(path string) (interface{}, bool) {
+	if value, ok := s.find(path); ok {
+		return value, true
+	}
+
+	segments := strings.Split(path, ".")
+	for n := len(segments) - 1; n > 0; n-- {
+		root, ok := s.find(strings.Join(segments[:n], "."))
+		if !ok {
+			continue
+		}
+
+		if str, isString := root.(string); isString {
+			decoded, err := decodeJSON(str)
+			if err != nil {
+				return nil, false
+			}
+			root = decoded
+		}
+
+		return walkJSON(root, segments[n:])
+	}
+
+	return nil, false
+}
+
+// clear Removes all the variables from the specified level.
+func (s *Scope) clear(level ScopeLevel) {
+	s.levels[level] = make(map[string]interface{})
+}
+
+// normalizeJSON Converts a value to the types produced by decoding JSON, with numbers as json.Number.
+func normalizeJSON(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil, string, bool, json.Number:
+		return value, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJSON(string(encoded))
+}
+
+// walkJSON Navigates a decoded JSON value using object keys and array indexes.
+func walkJSON(data interface{}, segments []string) (interface{}, bool) {
+	for _, segment := range segments {
+		switch v := data.(type) {
+		case map[string]interface{}:
+			field, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			data = field
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			data = v[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return data, true
+}
+
+// stringifyJSON Converts a decoded JSON value to its text representation. Strings are returned as is.
+func stringifyJSON(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	encoded, _ := json.Marshal(value)
+
+	return string(encoded)
+}
+
+// jsonLiteral Converts a scope value to a JSON literal.
+// Strings holding a JSON number, boolean or null, like "10" or "true", are inserted as is, so values stored as text
+// keep rendering as numbers. Other strings, including JSON objects and arrays, are rendered as JSON strings.
+func jsonLiteral(value interface{}) string {
+	if str, ok := value.(string); ok && isJSONScalar(str) {
+		return strings.TrimSpace(str)
+	}
+
+	encoded, _ := json.Marshal(value)
+
+	return string(encoded)
+}
+
+// isJSONScalar Checks if a string holds a JSON number, boolean or null.
+func isJSONScalar(str string) bool {
+	decoded, err := decodeJSON(str)
+	if err != nil {
+		return false
+	}
+
+	switch decoded.(type) {
+	case json.Number, bool, nil:
+		return true
+	}
+
+	return false
+}
+
+// jsonStringContent Escapes a scope value to be inserted inside a JSON string literal.
+func jsonStringContent(value interface{}) string {
+	encoded, _ := json.Marshal(stringifyJSON(value))
+
+	return string(encoded[1 : len(encoded)-1])
+}
+
+// replaceScopeVariables Replaces every "`##key`" placeholder in data with the value of the scope variable.
+// A default value can be specified with "`##key:-default`" and a literal backtick can be escaped with "\`".
+// Unknown variables without a default are replaced with an empty string, or return an error in strict mode.
+func (ctx *ApiContext) replaceScopeVariables(data string) (string, error) {
+	return ctx.expandScopePlaceholders(data, false, ctx.strictScope)
+}
+
+// replaceScopeVariablesInJSON Replaces the scope placeholders of a JSON document.
+// Placeholders outside string literals are replaced by the JSON literal of the value, preserving its type,
+// while placeholders inside string literals are replaced by the escaped text of the value.
+func (ctx *ApiContext) replaceScopeVariablesInJSON(data string) (string, error) {
+	return ctx.expandScopePlaceholders(data, true, ctx.strictScope)
+}
+
+// expandScopePlaceholders Scans data for scope placeholders. When jsonMode is set, it keeps track of JSON string literals
+// to render each value accordingly. When strict is set, undefined variables without a default return an error.
+func (ctx *ApiContext) expandScopePlaceholders(data string, jsonMode bool, strict bool) (string, error) {
+	var b strings.Builder
+	inString := false
+
+	for i := 0; i < len(data); {
+		if strings.HasPrefix(data[i:], "\\`") {
+			b.WriteByte('`')
+			i += 2
+			continue
+		}
+
+		if strings.HasPrefix(data[i:], "`##") {
+			end := strings.IndexByte(data[i+3:], '`')
+			if end > 0 {
+				value, err := ctx.resolveScopePlaceholder(data[i+3:i+3+end], jsonMode && !inString, strict)
+				if err != nil {
+					return "", err
+				}
+
+				switch {
+				case !jsonMode:
+					b.WriteString(stringifyJSON(value))
+				case inString:
+					b.WriteString(jsonStringContent(value))
+				default:
+					b.WriteString(jsonLiteral(value))
+				}
+
+				i += end + 4
+				continue
+			}
+		}
+
+		if jsonMode {
+			switch {
+			case data[i] == '"':
+				inString = !inString
+			case data[i] == '\\' && inString && i+1 < len(data):
+				b.WriteString(data[i : i+2])
+				i += 2
+				continue
+			}
+		}
+
+		b.WriteByte(data[i])
+		i++
+	}
+
+	return b.String(), nil
+}
+
+// resolveScopePlaceholder Resolves the expression inside a placeholder, in the form "key" or "key:-default".
+// When typed is set, the default value is parsed as JSON, so "`##page:-1`" defaults to a number,
+// and it is kept as a string when it is not valid JSON. When strict is set, an undefined variable returns an error.
+func (ctx *ApiContext) resolveScopePlaceholder(expr string, typed bool, strict bool) (interface{}, error) {
+	key, defaultValue, hasDefault := expr, "", false
+	if idx := strings.Index(expr, ":-"); idx >= 0 {
+		key, defaultValue, hasDefault = expr[:idx], expr[idx+2:], true
+	}
+
+	if value, ok := ctx.scope.Get(key); ok {
+		return value, nil
+	}
+
+	if hasDefault {
+		if typed {
+			if decoded, err := decodeJSON(defaultValue); err == nil {
+				return decoded, nil
+			}
+		}
+
+		return defaultValue, nil
+	}
+
+	if strict {
+		return nil, fmt.Errorf("scope variable %q is not defined", key)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,126 @@
+package apicontext
+
+import (
+	"testing"
+
+	"github.com/cucumber/godog"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope_Get(t *testing.T) {
+	s := newScope()
+
+	assert.Nil(t, s.Set(GlobalScope, "key", "global"))
+	assert.Nil(t, s.Set(FeatureScope, "key", "feature"))
+
+	value, ok := s.find("key")
+	assert.True(t, ok)
+	assert.Equal(t, "feature", value)
+
+	assert.Nil(t, s.Set(ScenarioScope, "key", "scenario"))
+	value, _ = s.find("key")
+	assert.Equal(t, "scenario", value)
+
+	_, ok = s.find("missing")
+	assert.False(t, ok)
+}
+
+func TestScope_SetInvalidLevel(t *testing.T) {
+	s := newScope()
+
+	assert.Error(t, s.Set(ScopeLevel("session"), "key", "value"))
+}
+
+func TestApiContext_ReplaceScopeVariablesAll(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Nil(t, ctx.StoreScopeData("first", "1"))
+	assert.Nil(t, ctx.StoreScopeData("second", "2"))
+
+	replaced, err := ctx.replaceScopeVariables("`##first` and `##second` and `##first`")
+	assert.Nil(t, err)
+	assert.Equal(t, "1 and 2 and 1", replaced)
+}
+
+func TestApiContext_ReplaceScopeVariablesDefaults(t *testing.T) {
+	ctx := setupTestContext().WithStrictScope(true)
+	assert.Nil(t, ctx.StoreScopeData("name", "godog"))
+
+	replaced, err := ctx.replaceScopeVariables("`##name:-other` `##missing:-fallback`")
+	assert.Nil(t, err)
+	assert.Equal(t, "godog fallback", replaced)
+
+	_, err = ctx.replaceScopeVariables("`##missing`")
+	assert.EqualError(t, err, `scope variable "missing" is not defined`)
+
+	ctx.WithStrictScope(false)
+	replaced, err = ctx.replaceScopeVariables("[`##missing`]")
+	assert.Nil(t, err)
+	assert.Equal(t, "[]", replaced)
+
+	replaced, err = ctx.replaceScopeVariablesInJSON("{\"page\": `##page:-1`, \"all\": `##all:-true`, \"tags\": `##tags:-[\"a\"]`, \"sort\": `##sort:-name`, \"label\": \"page `##page:-1`\"}")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"page": 1, "all": true, "tags": ["a"], "sort": "name", "label": "page 1"}`, replaced)
+}
+
+func TestApiContext_ReplaceScopeVariablesEscape(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Nil(t, ctx.StoreScopeData("name", "godog"))
+
+	replaced, err := ctx.replaceScopeVariables("\\`##name` is `##name`")
+	assert.Nil(t, err)
+	assert.Equal(t, "`##name` is godog", replaced)
+}
+
+func TestApiContext_ReplaceScopeVariablesNested(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Nil(t, ctx.StoreScopeData("user", `{"address": {"city": "Porto", "zip": 4000}, "tags": ["a", "b"]}`))
+
+	replaced, err := ctx.replaceScopeVariables("`##user.address.city` `##user.address.zip` `##user.tags.1` `##user.address`")
+	assert.Nil(t, err)
+	assert.Equal(t, `Porto 4000 b {"city":"Porto","zip":4000}`, replaced)
+
+	_, err = ctx.WithStrictScope(true).replaceScopeVariables("`##user.address.street`")
+	assert.Error(t, err)
+}
+
+func TestApiContext_ReplaceScopeVariablesInJSON(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Nil(t, ctx.SetScopeVariable(ScenarioScope, "id", 42))
+	assert.Nil(t, ctx.SetScopeVariable(ScenarioScope, "active", true))
+	assert.Nil(t, ctx.SetScopeVariable(ScenarioScope, "tags", []string{"a", "b"}))
+	assert.Nil(t, ctx.SetScopeVariable(ScenarioScope, "name", `say "hi"`))
+	assert.Nil(t, ctx.SetScopeVariable(ScenarioScope, "count", "10"))
+	assert.Nil(t, ctx.SetScopeVariable(ScenarioScope, "flag", "true"))
+
+	replaced, err := ctx.replaceScopeVariablesInJSON(
+		"{\"id\": `##id`, \"active\": `##active`, \"tags\": `##tags`, \"name\": `##name`, \"label\": \"#`##id` `##name`\", \"count\": `##count`, \"flag\": `##flag`}",
+	)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id": 42, "active": true, "tags": ["a", "b"], "name": "say \"hi\"", "label": "#42 say \"hi\"", "count": 10, "flag": true}`, replaced)
+}
+
+func TestApiContext_ReplaceScopeVariablesInJSONText(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Nil(t, ctx.StoreScopeData("count", "10"))
+	assert.Nil(t, ctx.StoreScopeData("filter", `{"a": 1}`))
+	assert.Nil(t, ctx.StoreScopeData("zip", "4000"))
+
+	replaced, err := ctx.replaceScopeVariablesInJSON("{\"count\": `##count`, \"filter\": `##filter`, \"zip\": \"`##zip`\"}")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"count": 10, "filter": "{\"a\": 1}", "zip": "4000"}`, replaced)
+}
+
+func TestApiContext_TheScopeVariableShouldMatchJSON(t *testing.T) {
+	ctx := setupTestContext()
+	assert.Nil(t, ctx.SetScopeVariable(ScenarioScope, "user", map[string]interface{}{
+		"name": "godog",
+		"tags": []string{"a"},
+	}))
+
+	assert.Nil(t, ctx.TheScopeVariableShouldMatchJSON("user", &godog.DocString{Content: `{"tags": ["a"], "name": "godog"}`}))
+	assert.Error(t, ctx.TheScopeVariableShouldMatchJSON("user", &godog.DocString{Content: `{"name": "godog"}`}))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("user.tags", `["a"]`))
+	assert.Error(t, ctx.TheScopeVariableShouldMatchJSON("missing", &godog.DocString{Content: `{}`}))
+}
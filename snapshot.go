@@ -0,0 +1,265 @@
+package apicontext
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// defaultSnapshotsPath The default path where response snapshots are stored.
+	defaultSnapshotsPath = "snapshots"
+	// snapshotExtension The extension of snapshot files.
+	snapshotExtension = ".snap"
+	// snapshotMaskValue The value that replaces masked fields in snapshots.
+	snapshotMaskValue = "<masked>"
+	// envUpdateSnapshots Environment variable that rewrites the snapshots with the actual responses when set to true.
+	envUpdateSnapshots = "UPDATE_SNAPSHOTS"
+)
+
+// WithSnapshotsPath Specifies the path where response snapshots are stored.
+func (ctx *ApiContext) WithSnapshotsPath(path string) *ApiContext {
+	ctx.snapshotsPath = path
+	return ctx
+}
+
+// WithSnapshotMask Specifies json paths of volatile fields, like ids or timestamps, that are masked in every snapshot.
+func (ctx *ApiContext) WithSnapshotMask(paths ...string) *ApiContext {
+	ctx.snapshotMasks = append(ctx.snapshotMasks, paths...)
+	return ctx
+}
+
+// WithSnapshotUpdate Configures if snapshots should be rewritten with the actual responses instead of being compared.
+// Snapshots are also updated when the UPDATE_SNAPSHOTS environment variable is set to true.
+func (ctx *ApiContext) WithSnapshotUpdate(update bool) *ApiContext {
+	ctx.updateSnapshots = update
+	return ctx
+}
+
+// IMaskJSONPathInSnapshots Masks the field at the specified json path in the snapshots of the current scenario.
+func (ctx *ApiContext) IMaskJSONPathInSnapshots(pathExpr string) error {
+	if _, err := parseMaskPath(pathExpr); err != nil {
+		return err
+	}
+
+	ctx.scenarioSnapshotMasks = append(ctx.scenarioSnapshotMasks, pathExpr)
+	return nil
+}
+
+// TheResponseShouldMatchSnapshot Compares the normalized response body with a snapshot file.
+// JSON bodies are stored with sorted keys and with the masked fields replaced.
+// The snapshot is created when it does not exist yet, and rewritten when snapshot updates are enabled.
+func (ctx *ApiContext) TheResponseShouldMatchSnapshot(name string) error {
+	response, err := ctx.response()
+	if err != nil {
+		return err
+	}
+
+	actual, err := ctx.normalizeSnapshot(response.Body)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(ctx.snapshotsPath, filepath.FromSlash(strings.Trim(name, "/"))+snapshotExtension)
+
+	expected, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) || err == nil && ctx.shouldUpdateSnapshots() {
+		return writeSnapshot(path, actual)
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot read snapshot file: %v", err)
+	}
+
+	if string(expected) == actual {
+		return nil
+	}
+
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(expected)),
+		B:        difflib.SplitLines(actual),
+		FromFile: "snapshot " + name,
+		ToFile:   "response",
+		Context:  3,
+	})
+
+	return fmt.Errorf("the response does not match snapshot %s. Set %s=true to update it.\n%s", name, envUpdateSnapshots, diff)
+}
+
+// shouldUpdateSnapshots Checks if the snapshots should be rewritten.
+func (ctx *ApiContext) shouldUpdateSnapshots() bool {
+	update, _ := strconv.ParseBool(os.Getenv(envUpdateSnapshots))
+	return ctx.updateSnapshots || update
+}
+
+// normalizeSnapshot Converts a response body to its snapshot representation.
+// Bodies that are not valid JSON are stored as is. Numbers are written exactly as they were received.
+func (ctx *ApiContext) normalizeSnapshot(body string) (string, error) {
+	data, err := decodeJSON(body)
+	if err != nil {
+		return body, nil
+	}
+
+	masks := append(append([]string(nil), ctx.snapshotMasks...), ctx.scenarioSnapshotMasks...)
+	for _, mask := range masks {
+		segments, err := parseMaskPath(mask)
+		if err != nil {
+			return "", err
+		}
+		maskJSON(data, segments)
+	}
+
+	var normalized strings.Builder
+	encoder := json.NewEncoder(&normalized)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(data); err != nil {
+		return "", err
+	}
+
+	return normalized.String(), nil
+}
+
+// writeSnapshot Writes a snapshot file, creating its directory if needed.
+func writeSnapshot(path string, contents string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("cannot create snapshots directory: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		return fmt.Errorf("cannot write snapshot file: %v", err)
+	}
+
+	return nil
+}
+
+// maskSegment A segment of a mask path. An empty key with index -1 matches every field or item.
+type maskSegment struct {
+	key       string
+	index     int
+	recursive bool
+}
+
+// parseMaskPath Parses the json paths supported by snapshot masks: "$.a.b", "$.items[0].id", "$.items[*].id", "$['a']" and "$..id".
+func parseMaskPath(path string) ([]maskSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid mask path %s: it must start with $", path)
+	}
+
+	var segments []maskSegment
+	rest := path[1:]
+
+	for rest != "" {
+		recursive := strings.HasPrefix(rest, "..")
+
+		switch {
+		case recursive || rest[0] == '.':
+			rest = strings.TrimPrefix(strings.TrimPrefix(rest, "."), ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("invalid mask path %s: empty field name", path)
+			}
+
+			segment := maskSegment{key: key, index: -1, recursive: recursive}
+			if key == "*" {
+				segment.key = ""
+			}
+
+			segments = append(segments, segment)
+			rest = rest[end:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid mask path %s: unclosed bracket", path)
+			}
+
+			selector := rest[1:end]
+			switch {
+			case selector == "*":
+				segments = append(segments, maskSegment{index: -1})
+			case strings.HasPrefix(selector, "'") && strings.HasSuffix(selector, "'") && len(selector) > 1:
+				segments = append(segments, maskSegment{key: selector[1 : len(selector)-1], index: -1})
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid mask path %s: invalid index %s", path, selector)
+				}
+				segments = append(segments, maskSegment{index: index})
+			}
+
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid mask path %s: unexpected %q", path, rest[0])
+		}
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid mask path %s: the root cannot be masked", path)
+	}
+
+	return segments, nil
+}
+
+// maskJSON Replaces the values at the path defined by segments with the mask value.
+func maskJSON(data interface{}, segments []maskSegment) {
+	segment, last := segments[0], len(segments) == 1
+
+	if segment.recursive {
+		walkJSONChildren(data, func(child interface{}) {
+			maskJSON(child, segments)
+		})
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if segment.key != "" && key != segment.key || segment.key == "" && segment.index >= 0 {
+				continue
+			}
+
+			if last {
+				v[key] = snapshotMaskValue
+			} else {
+				maskJSON(child, segments[1:])
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if segment.key != "" || segment.index >= 0 && segment.index != i {
+				continue
+			}
+
+			if last {
+				v[i] = snapshotMaskValue
+			} else {
+				maskJSON(child, segments[1:])
+			}
+		}
+	}
+}
+
+// walkJSONChildren Calls fn for each direct child of an object or array.
+func walkJSONChildren(data interface{}, fn func(child interface{})) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			fn(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			fn(child)
+		}
+	}
+}
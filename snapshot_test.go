@@ -0,0 +1,95 @@
+package apicontext
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiContext_TheResponseShouldMatchSnapshot(t *testing.T) {
+	body := `{"total": 1, "items": [{"status": "active", "id": 123, "createdAt": "2021-01-01T00:00:00Z"}]}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithSnapshotsPath(filepath.Join("testdata", "snapshots")).
+		WithSnapshotMask("$.items[*].createdAt")
+
+	assert.Nil(t, ctx.IMaskJSONPathInSnapshots("$..id"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheResponseShouldMatchSnapshot("orders/list"))
+
+	body = `{"total": 2, "items": [{"status": "active", "id": 123, "createdAt": "2021-01-01T00:00:00Z"}]}`
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+
+	err := ctx.TheResponseShouldMatchSnapshot("orders/list")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "-  \"total\": 1\n+  \"total\": 2")
+}
+
+func TestApiContext_TheResponseShouldMatchSnapshotCreateAndUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	assert.Nil(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	body := "hello world"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithSnapshotsPath(dir)
+
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheResponseShouldMatchSnapshot("text/hello"))
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "text", "hello.snap"))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", string(contents))
+
+	body = "hello godog"
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Error(t, ctx.TheResponseShouldMatchSnapshot("text/hello"))
+	assert.Nil(t, ctx.WithSnapshotUpdate(true).TheResponseShouldMatchSnapshot("text/hello"))
+	assert.Nil(t, ctx.WithSnapshotUpdate(false).TheResponseShouldMatchSnapshot("text/hello"))
+
+	body = `{"id": 12345678901234567891, "price": 10.50}`
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Nil(t, ctx.TheResponseShouldMatchSnapshot("json/ids"))
+
+	contents, err = ioutil.ReadFile(filepath.Join(dir, "json", "ids.snap"))
+	assert.Nil(t, err)
+	assert.Equal(t, "{\n  \"id\": 12345678901234567891,\n  \"price\": 10.50\n}\n", string(contents))
+
+	body = `{"id": 12345678901234567890, "price": 10.50}`
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Error(t, ctx.TheResponseShouldMatchSnapshot("json/ids"))
+}
+
+func TestParseMaskPath(t *testing.T) {
+	segments, err := parseMaskPath("$.items[0]['created at']..id")
+	assert.Nil(t, err)
+	assert.Equal(t, []maskSegment{
+		{key: "items", index: -1},
+		{index: 0},
+		{key: "created at", index: -1},
+		{key: "id", index: -1, recursive: true},
+	}, segments)
+
+	_, err = parseMaskPath("items")
+	assert.Error(t, err)
+	_, err = parseMaskPath("$")
+	assert.Error(t, err)
+	_, err = parseMaskPath("$.items[x]")
+	assert.Error(t, err)
+}
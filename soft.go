@@ -0,0 +1,84 @@
+package apicontext
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/cucumber/godog"
+)
+
+// softAssertionsTag The scenario tag that enables soft assertions.
+const softAssertionsTag = "@soft"
+
+// errorType The reflect type of the error interface, used to reset the error returned by a soft assertion.
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// assertionFailure A failed assertion collected in soft assertions mode.
+type assertionFailure struct {
+	step string
+	err  error
+}
+
+// softAssertion Wraps an assertion step, so that when soft assertions are enabled, a failure is collected
+// instead of stopping the scenario. The collected failures are reported together when the scenario ends.
+func (ctx *ApiContext) softAssertion(step interface{}) interface{} {
+	fn := reflect.ValueOf(step)
+
+	return reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+		results := fn.Call(args)
+		last := len(results) - 1
+
+		if !ctx.softAssertions || last < 0 || results[last].IsNil() {
+			return results
+		}
+
+		ctx.assertionFailures = append(ctx.assertionFailures, assertionFailure{
+			step: ctx.currentStep,
+			err:  results[last].Interface().(error),
+		})
+		results[last] = reflect.Zero(errorType)
+
+		return results
+	}).Interface()
+}
+
+// ICollectAssertionFailures Enables soft assertions for the current scenario.
+// Failed assertions no longer stop the scenario, and are reported together when it ends.
+func (ctx *ApiContext) ICollectAssertionFailures() error {
+	ctx.softAssertions = true
+	return nil
+}
+
+// beforeSoftAssertionsScenario Enables soft assertions for scenarios tagged with @soft.
+func (ctx *ApiContext) beforeSoftAssertionsScenario(sc *godog.Scenario) {
+	ctx.softAssertions = false
+	ctx.assertionFailures = nil
+
+	for _, tag := range sc.Tags {
+		if tag.Name == softAssertionsTag {
+			ctx.softAssertions = true
+		}
+	}
+}
+
+// beforeStep Keeps track of the running step, to identify the collected assertion failures.
+func (ctx *ApiContext) beforeStep(goCtx context.Context, st *godog.Step) (context.Context, error) {
+	ctx.currentStep = st.Text
+	return goCtx, nil
+}
+
+// reportAssertionFailures Fails the scenario with all the assertion failures collected in soft assertions mode.
+func (ctx *ApiContext) reportAssertionFailures(goCtx context.Context, _ *godog.Scenario, _ error) (context.Context, error) {
+	if len(ctx.assertionFailures) == 0 {
+		return goCtx, nil
+	}
+
+	messages := make([]string, len(ctx.assertionFailures))
+	for i, failure := range ctx.assertionFailures {
+		messages[i] = fmt.Sprintf("%d. %s: %v", i+1, failure.step, failure.err)
+	}
+
+	return goCtx, fmt.Errorf("%d assertion(s) failed:\n%s", len(ctx.assertionFailures), strings.Join(messages, "\n"))
+}
@@ -0,0 +1,84 @@
+package apicontext
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+const softAssertionsFeature = `Feature: Soft assertions
+  @soft
+  Scenario: Collects every failure
+    When I send "GET" request to "/"
+    Then The json path "$.name" should have value "other"
+    And The response header "X-Version" should have value "2"
+    And The json path "$.count" should have value "1"
+`
+
+func runTestSuite(t *testing.T, ctx *ApiContext, feature string) (int, string) {
+	var output bytes.Buffer
+
+	status := godog.TestSuite{
+		Name:                "apicontext",
+		ScenarioInitializer: ctx.InitializeScenario,
+		Options: &godog.Options{
+			Format:          "pretty",
+			NoColors:        true,
+			Output:          &output,
+			FeatureContents: []godog.Feature{{Name: "test.feature", Contents: []byte(feature)}},
+		},
+	}.Run()
+
+	t.Log(output.String())
+
+	return status, output.String()
+}
+
+func TestApiContext_SoftAssertions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Version", "1")
+		_, _ = w.Write([]byte(`{"name": "godog", "count": 1}`))
+	}))
+
+	defer ts.Close()
+
+	ctx := setupTestContext().WithBaseURL(ts.URL).WithDebug(false)
+
+	status, output := runTestSuite(t, ctx, softAssertionsFeature)
+
+	assert.Equal(t, 1, status)
+	assert.Contains(t, output, "2 assertion(s) failed")
+	assert.Contains(t, output, `1. The json path "$.name" should have value "other": expected json path to have value other but it is godog`)
+	assert.Contains(t, output, `2. The response header "X-Version" should have value "2": expected header to have value 2. actual : 1`)
+}
+
+func TestApiContext_SoftAssertionsStep(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Version", "1")
+	}))
+
+	defer ts.Close()
+
+	ctx := setupTestContext().WithBaseURL(ts.URL)
+	ctx.reset(&godog.Scenario{})
+
+	assert.Nil(t, ctx.ICollectAssertionFailures())
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+
+	step := ctx.softAssertion(ctx.TheResponseHeaderShouldHaveValue).(func(string, string) error)
+	assert.Nil(t, step("X-Version", "2"))
+	assert.Nil(t, step("X-Version", "1"))
+	assert.Len(t, ctx.assertionFailures, 1)
+
+	_, err := ctx.reportAssertionFailures(context.Background(), nil, nil)
+	assert.Error(t, err)
+
+	ctx.reset(&godog.Scenario{})
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/"))
+	assert.Error(t, step("X-Version", "2"))
+}
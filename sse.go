@@ -0,0 +1,252 @@
+package apicontext
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// defaultEventType The type of the server-sent events without an "event" field.
+const defaultEventType = "message"
+
+// sseEvent A server-sent event.
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// eventStream An open text/event-stream connection, read in the background.
+// The events channel is closed when the stream ends, after err is set.
+type eventStream struct {
+	cancel    context.CancelFunc
+	events    chan sseEvent
+	err       error
+	lastEvent *sseEvent
+}
+
+// IOpenAnEventStreamTo Opens a server-sent events stream to the specified endpoint.
+// The request has the headers, query params and path params of the current scenario,
+// and the events are received in the background until the stream is closed.
+func (ctx *ApiContext) IOpenAnEventStreamTo(uri string) error {
+	ctx.closeEventStream()
+
+	req, err := ctx.newRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req = req.WithContext(streamCtx)
+
+	ctx.logRequest(req)
+	ctx.lastRequest = req
+
+	// The client timeout would also apply to reading the body, and close long-lived streams.
+	client := *ctx.client
+	client.Timeout = 0
+
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || mediaType != "text/event-stream" {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		cancel()
+
+		return fmt.Errorf("cannot open event stream: status code %d, content type %q.\n Response body: %s", resp.StatusCode, mediaType, body)
+	}
+
+	stream := &eventStream{cancel: cancel, events: make(chan sseEvent, 64)}
+	ctx.eventStream = stream
+
+	go ctx.readEvents(stream, resp.Body, streamCtx)
+
+	return nil
+}
+
+// IShouldReceiveAnEventWithin Waits for an event of the specified type. Events of other types received before are skipped.
+func (ctx *ApiContext) IShouldReceiveAnEventWithin(eventType string, seconds int) error {
+	_, err := ctx.waitForEvent(eventType, seconds, nil)
+	return err
+}
+
+// IShouldReceiveAnEventWithinWithJSON Waits for an event of the specified type whose data matches the expected JSON.
+// Events of other types, or with different data, received before are skipped.
+func (ctx *ApiContext) IShouldReceiveAnEventWithinWithJSON(eventType string, seconds int, body *godog.DocString) error {
+	expected, err := ctx.replaceScopeVariablesInJSON(body.Content)
+	if err != nil {
+		return err
+	}
+
+	expectedValue, err := decodeJSON(expected)
+	if err != nil {
+		return fmt.Errorf("invalid expected json: %v", err)
+	}
+
+	_, err = ctx.waitForEvent(eventType, seconds, func(event sseEvent) bool {
+		actualValue, err := decodeJSON(event.Data)
+		return err == nil && ctx.valuesEqual(actualValue, expectedValue)
+	})
+
+	return err
+}
+
+// StoreEventPathValueIn Stores the value at the specified json path of the data of the last received event in the specified scope level.
+func (ctx *ApiContext) StoreEventPathValueIn(pathExpr string, scopeKeyName string, level string) error {
+	if ctx.eventStream == nil || ctx.eventStream.lastEvent == nil {
+		return fmt.Errorf("no event was received in this scenario")
+	}
+
+	data, err := decodeJSON(ctx.eventStream.lastEvent.Data)
+	if err != nil {
+		return fmt.Errorf("the data of event %q is not valid json: %v", ctx.eventStream.lastEvent.Type, err)
+	}
+
+	value, err := evaluateJSONPath(data, pathExpr)
+	if err != nil {
+		return err
+	}
+
+	return ctx.scope.Set(ScopeLevel(level), scopeKeyName, value)
+}
+
+// ICloseTheEventStream Closes the event stream opened in the current scenario.
+func (ctx *ApiContext) ICloseTheEventStream() error {
+	if ctx.eventStream == nil {
+		return fmt.Errorf("no event stream is open")
+	}
+
+	ctx.closeEventStream()
+
+	return nil
+}
+
+// closeEventStream Closes the event stream, if any. It is also called when the scenario ends.
+func (ctx *ApiContext) closeEventStream() {
+	if ctx.eventStream == nil {
+		return
+	}
+
+	ctx.eventStream.cancel()
+	ctx.eventStream = nil
+}
+
+// waitForEvent Reads events until one has the specified type and matches, or the timeout expires.
+func (ctx *ApiContext) waitForEvent(eventType string, seconds int, match func(event sseEvent) bool) (sseEvent, error) {
+	stream := ctx.eventStream
+	if stream == nil {
+		return sseEvent{}, fmt.Errorf("no event stream is open")
+	}
+
+	timeout := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timeout.Stop()
+
+	var skipped *sseEvent
+	for {
+		select {
+		case event, ok := <-stream.events:
+			if !ok {
+				return sseEvent{}, fmt.Errorf("the event stream was closed before receiving an event %q: %v", eventType, stream.err)
+			}
+
+			if event.Type != eventType {
+				continue
+			}
+
+			if match != nil && !match(event) {
+				skipped = &event
+				continue
+			}
+
+			stream.lastEvent = &event
+
+			return event, nil
+		case <-timeout.C:
+			if skipped != nil {
+				return sseEvent{}, fmt.Errorf("no matching event %q received within %d seconds. last event data: %s", eventType, seconds, skipped.Data)
+			}
+
+			return sseEvent{}, fmt.Errorf("no event %q received within %d seconds", eventType, seconds)
+		}
+	}
+}
+
+// readEvents Parses the stream according to the server-sent events format and publishes the events until the stream ends.
+func (ctx *ApiContext) readEvents(stream *eventStream, body io.ReadCloser, streamCtx context.Context) {
+	defer close(stream.events)
+	defer body.Close()
+
+	reader := bufio.NewReader(body)
+	event := sseEvent{}
+	var data []string
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("end of stream")
+			}
+			stream.err = err
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if data != nil {
+				event.Data = strings.Join(data, "\n")
+				if event.Type == "" {
+					event.Type = defaultEventType
+				}
+
+				if ctx.debug {
+					log.Printf("event: %s\ndata: %s\n", event.Type, event.Data)
+				}
+
+				select {
+				case stream.events <- event:
+				case <-streamCtx.Done():
+					stream.err = streamCtx.Err()
+					return
+				}
+			}
+
+			event, data = sseEvent{ID: event.ID}, nil
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if idx := strings.IndexByte(line, ':'); idx >= 0 {
+			field, value = line[:idx], strings.TrimPrefix(line[idx+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event.Type = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			event.ID = value
+		}
+	}
+}
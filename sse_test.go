@@ -0,0 +1,99 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cucumber/godog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEventsServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+
+		_, _ = fmt.Fprint(w, ": connected\n\n")
+		_, _ = fmt.Fprint(w, "data: ping\n\n")
+		_, _ = fmt.Fprint(w, "event: order.created\r\nid: 1\r\ndata: {\"id\": 1, \"total\": 9.50}\r\n\r\n")
+		_, _ = fmt.Fprintf(w, "event: order.created\nid: 2\ndata: {\"id\": 2,\ndata:  \"tenant\": %q}\n\n", r.Header.Get("X-Tenant"))
+		flusher.Flush()
+
+		<-r.Context().Done()
+	}))
+}
+
+func TestApiContext_EventStream(t *testing.T) {
+	ts := newEventsServer()
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Error(t, ctx.IShouldReceiveAnEventWithin("message", 1))
+	assert.Error(t, ctx.IOpenAnEventStreamTo("/missing"))
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("X-Tenant", "acme"))
+	assert.Nil(t, ctx.IOpenAnEventStreamTo("/events"))
+	assert.Equal(t, "text/event-stream", ctx.LastRequest().Header.Get("Accept"))
+
+	assert.Nil(t, ctx.IShouldReceiveAnEventWithin("message", 1))
+	assert.Nil(t, ctx.IShouldReceiveAnEventWithinWithJSON("order.created", 1, &godog.DocString{Content: `{"id": 2, "tenant": "acme"}`}))
+	assert.Equal(t, "2", ctx.eventStream.lastEvent.ID)
+
+	assert.Nil(t, ctx.StoreEventPathValueIn("$.tenant", "tenant", "scenario"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("tenant", "acme"))
+
+	assert.EqualError(t, ctx.IShouldReceiveAnEventWithin("order.created", 1), `no event "order.created" received within 1 seconds`)
+
+	assert.Nil(t, ctx.ICloseTheEventStream())
+	assert.Error(t, ctx.ICloseTheEventStream())
+}
+
+func TestApiContext_EventStreamSkipsNotMatchingEvents(t *testing.T) {
+	ts := newEventsServer()
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Nil(t, ctx.IOpenAnEventStreamTo("/events"))
+	assert.EqualError(t, ctx.IShouldReceiveAnEventWithinWithJSON("order.created", 1, &godog.DocString{Content: `{"id": 3}`}),
+		`no matching event "order.created" received within 1 seconds. last event data: {"id": 2,`+"\n"+` "tenant": ""}`)
+
+	ctx.reset(&godog.Scenario{Uri: "test.feature"})
+	assert.Nil(t, ctx.eventStream)
+}
+
+const eventStreamFeature = `Feature: events
+  Scenario: order events
+    When I open an event stream to "/events"
+    Then I should receive an event "order.created" within 2 seconds with json:
+      """
+      {"id": 1, "total": 9.5}
+      """
+    And I store the value of event path "$.id" as "orderId" in scenario scope
+    And The scope variable "orderId" should have value "1"
+    And I close the event stream
+`
+
+func TestApiContext_EventStreamSteps(t *testing.T) {
+	ts := newEventsServer()
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	status, _ := runTestSuite(t, ctx, eventStreamFeature)
+	assert.Equal(t, 0, status)
+}
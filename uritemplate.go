@@ -0,0 +1,73 @@
+package apicontext
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reservedURIChars Characters that are kept as is by reserved expansion ({+var}), as defined in RFC 6570.
+const reservedURIChars = ":/?#[]@!$&'()*+,;="
+
+// uriTemplateVarname Matches a variable name of a URI template, as defined in RFC 6570:
+// alphanumerics, "_" and percent-encoded characters, with single dots between them.
+var uriTemplateVarname = regexp.MustCompile(`^(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})+(?:\.(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})+)*$`)
+
+// expandURITemplate Expands the variables of a URI template like "/users/{id}/orders/{orderId}".
+// Simple expansion ({var}) percent-encodes every character except the unreserved ones, so values
+// with spaces or slashes stay inside a single path segment. Reserved expansion ({+var}) keeps the reserved characters.
+// Braces that do not hold a valid RFC 6570 variable name, like in "?filter={}", are kept as is.
+func expandURITemplate(template string, lookup func(name string) (string, bool)) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(template); {
+		end := strings.IndexByte(template[i:], '}')
+		if template[i] != '{' || end < 0 {
+			b.WriteByte(template[i])
+			i++
+			continue
+		}
+
+		expr := template[i+1 : i+end]
+		reserved := strings.HasPrefix(expr, "+")
+		name := strings.TrimPrefix(expr, "+")
+
+		if !uriTemplateVarname.MatchString(name) {
+			b.WriteByte(template[i])
+			i++
+			continue
+		}
+
+		value, ok := lookup(name)
+		if !ok {
+			return "", fmt.Errorf("missing path param %q for URI template %s", name, template)
+		}
+
+		b.WriteString(encodeURIValue(value, reserved))
+		i += end + 1
+	}
+
+	return b.String(), nil
+}
+
+// encodeURIValue Percent-encodes a value for a URI template expansion.
+func encodeURIValue(value string, reserved bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isUnreservedURIChar(c) || reserved && strings.IndexByte(reservedURIChars, c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+// isUnreservedURIChar Checks if a character is unreserved, as defined in RFC 3986.
+func isUnreservedURIChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
@@ -0,0 +1,57 @@
+package apicontext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandURITemplate(t *testing.T) {
+	params := map[string]string{
+		"id":   "a b/c",
+		"path": "docs/a b.txt",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := params[name]
+		return value, ok
+	}
+
+	expanded, err := expandURITemplate("/users/{id}/files/{+path}?x=1", lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, "/users/a%20b%2Fc/files/docs/a%20b.txt?x=1", expanded)
+
+	_, err = expandURITemplate("/users/{missing}", lookup)
+	assert.EqualError(t, err, `missing path param "missing" for URI template /users/{missing}`)
+
+	for _, literal := range []string{"/users/{id", "/search?filter={}", `/search?q={"a":1}`, "/search?q={a b}", "/x/{.id}", "/x/{+}"} {
+		expanded, err = expandURITemplate(literal, lookup)
+		assert.Nil(t, err, literal)
+		assert.Equal(t, literal, expanded)
+	}
+
+	expanded, err = expandURITemplate(`/users/{id}?q={"a":{id}}`, lookup)
+	assert.Nil(t, err)
+	assert.Equal(t, `/users/a%20b%2Fc?q={"a":a%20b%2Fc}`, expanded)
+}
+
+func TestApiContext_ISetPathParamWithValue(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.EscapedPath())
+	}))
+
+	defer ts.Close()
+
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL)
+
+	assert.Nil(t, ctx.StoreScopeData("orderId", "42"))
+	assert.Nil(t, ctx.ISetPathParamWithValue("id", "john doe/1"))
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/users/{id}/orders/{orderId}"))
+	assert.Nil(t, ctx.TheResponseHeaderShouldHaveValue("X-Path", "/users/john%20doe%2F1/orders/42"))
+
+	assert.EqualError(t, ctx.ISendRequestTo("GET", "/users/{userId}"), `missing path param "userId" for URI template /users/{userId}`)
+	assert.Nil(t, ctx.ISendRequestTo("GET", "/search?filter={}"))
+	assert.Equal(t, "{}", ctx.LastRequest().URL.Query().Get("filter"))
+}
@@ -1,25 +1,36 @@
 package apicontext
 
 import (
-	"encoding/json"
-	"reflect"
+	"github.com/cucumber/godog"
 )
 
-func isEqualJson(s1, s2 string) (bool, error) {
-	var o1 interface{}
-	var o2 interface{}
-
-	err := json.Unmarshal([]byte(s1), &o1)
-
-	if err != nil {
-		return false, err
+// mergeValues Returns a copy of base with the keys of override replacing the keys of base.
+func mergeValues(base map[string][]string, override map[string][]string) map[string][]string {
+	merged := make(map[string][]string, len(base)+len(override))
+	for key, values := range base {
+		merged[key] = append([]string(nil), values...)
+	}
+	for key, values := range override {
+		merged[key] = append([]string(nil), values...)
 	}
 
-	err = json.Unmarshal([]byte(s2), &o2)
+	return merged
+}
+
+// setValuesFromTable Calls set for each name and value pair of a two column Data Table, after replacing the scope variables.
+// first is true for the first occurrence of a name in the table, to allow repeated names to add multiple values.
+func setValuesFromTable(dt *godog.Table, replace func(string) (string, error), set func(name string, value string, first bool)) error {
+	seen := make(map[string]bool)
+	for i := 0; i < len(dt.Rows); i++ {
+		name := dt.Rows[i].Cells[0].Value
+		value, err := replace(dt.Rows[i].Cells[1].Value)
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		return false, err
+		set(name, value, !seen[name])
+		seen[name] = true
 	}
 
-	return reflect.DeepEqual(o1, o2), nil
+	return nil
 }
@@ -0,0 +1,309 @@
+package apicontext
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/gorilla/websocket"
+)
+
+// webSocketCloseTimeout The time to wait for the server to acknowledge a close frame.
+const webSocketCloseTimeout = 5 * time.Second
+
+// webSocketHandshakeHeaders The headers set by the websocket client, that cannot be sent from the scenario headers.
+var webSocketHandshakeHeaders = []string{"Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions"}
+
+// webSocket An open websocket connection, whose messages are read in the background.
+// The messages channel is closed when the connection ends, after err and closeCode are set.
+type webSocket struct {
+	conn        *websocket.Conn
+	messages    chan string
+	done        chan struct{}
+	err         error
+	closeCode   int
+	lastMessage *string
+}
+
+// IConnectToWebSocket Opens a websocket connection to the specified endpoint.
+// The handshake request has the headers, query params and path params of the current scenario,
+// and http and https base URLs are switched to ws and wss.
+func (ctx *ApiContext) IConnectToWebSocket(uri string) error {
+	ctx.closeWebSocket()
+
+	req, err := ctx.newRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+
+	switch req.URL.Scheme {
+	case "http":
+		req.URL.Scheme = "ws"
+	case "https":
+		req.URL.Scheme = "wss"
+	}
+
+	for _, name := range webSocketHandshakeHeaders {
+		req.Header.Del(name)
+	}
+
+	ctx.logRequest(req)
+	ctx.lastRequest = req
+
+	dialer := websocket.Dialer{HandshakeTimeout: ctx.client.Timeout, Proxy: http.ProxyFromEnvironment}
+	if transport, ok := ctx.client.Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.TLSClientConfig = transport.TLSClientConfig
+	}
+
+	conn, resp, err := dialer.Dial(req.URL.String(), req.Header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("cannot connect to websocket %s: %v, status code %d", req.URL, err, resp.StatusCode)
+		}
+
+		return fmt.Errorf("cannot connect to websocket %s: %v", req.URL, err)
+	}
+
+	ws := &webSocket{conn: conn, messages: make(chan string, 64), done: make(chan struct{})}
+	ctx.webSocket = ws
+
+	go ctx.readWebSocketMessages(ws)
+
+	return nil
+}
+
+// ISendWebSocketMessage Sends a text message on the websocket. Scope placeholders are replaced by their values.
+func (ctx *ApiContext) ISendWebSocketMessage(message string) error {
+	message, err := ctx.replaceScopeVariables(message)
+	if err != nil {
+		return err
+	}
+
+	return ctx.writeWebSocketMessage(message)
+}
+
+// ISendWebSocketMessageWithJSON Sends a JSON message on the websocket. Scope placeholders are replaced by JSON literals.
+func (ctx *ApiContext) ISendWebSocketMessageWithJSON(body *godog.DocString) error {
+	message, err := ctx.replaceScopeVariablesInJSON(body.Content)
+	if err != nil {
+		return err
+	}
+
+	if _, err := decodeJSON(message); err != nil {
+		return fmt.Errorf("invalid json message: %v", err)
+	}
+
+	return ctx.writeWebSocketMessage(message)
+}
+
+// IShouldReceiveAWebSocketMessageWithJSONPathWithin Waits for a JSON message with the expected value at the specified json path.
+// Messages received before, that do not match, are skipped.
+func (ctx *ApiContext) IShouldReceiveAWebSocketMessageWithJSONPathWithin(pathExpr string, expectedValue string, seconds int) error {
+	expectedValue, err := ctx.replaceScopeVariables(expectedValue)
+	if err != nil {
+		return err
+	}
+
+	return ctx.waitForWebSocketMessage(seconds, fmt.Sprintf("with json path %s having value %s", pathExpr, expectedValue), func(message string) bool {
+		data, err := decodeJSON(message)
+		if err != nil {
+			return false
+		}
+
+		actualValue, err := evaluateJSONPath(data, pathExpr)
+		if err != nil {
+			return false
+		}
+
+		match, err := ctx.valueMatches(actualValue, expectedValue)
+		return err == nil && match
+	})
+}
+
+// IShouldReceiveAWebSocketMessageMatchingWithin Waits for a message matching the specified pattern.
+// Messages received before, that do not match, are skipped.
+func (ctx *ApiContext) IShouldReceiveAWebSocketMessageMatchingWithin(pattern string, seconds int) error {
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	return ctx.waitForWebSocketMessage(seconds, fmt.Sprintf("matching %s", pattern), r.MatchString)
+}
+
+// StoreWebSocketMessagePathValueIn Stores the value at the specified json path of the last received websocket message in the specified scope level.
+func (ctx *ApiContext) StoreWebSocketMessagePathValueIn(pathExpr string, scopeKeyName string, level string) error {
+	if ctx.webSocket == nil || ctx.webSocket.lastMessage == nil {
+		return fmt.Errorf("no websocket message was received in this scenario")
+	}
+
+	data, err := decodeJSON(*ctx.webSocket.lastMessage)
+	if err != nil {
+		return fmt.Errorf("the websocket message is not valid json: %v", err)
+	}
+
+	value, err := evaluateJSONPath(data, pathExpr)
+	if err != nil {
+		return err
+	}
+
+	return ctx.scope.Set(ScopeLevel(level), scopeKeyName, value)
+}
+
+// ICloseTheWebSocketWithCode Closes the websocket with the specified close code,
+// and checks that the server acknowledges it with the same code.
+func (ctx *ApiContext) ICloseTheWebSocketWithCode(code int) error {
+	ws := ctx.webSocket
+	if ws == nil {
+		return fmt.Errorf("no websocket is open")
+	}
+
+	defer ctx.closeWebSocket()
+
+	err := ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), time.Now().Add(webSocketCloseTimeout))
+	if err != nil {
+		return fmt.Errorf("cannot close the websocket: %v", err)
+	}
+
+	if err := waitForWebSocketClose(ws, webSocketCloseTimeout); err != nil {
+		return err
+	}
+
+	if ws.closeCode != code {
+		return fmt.Errorf("expected the websocket close to be acknowledged with code %d, but actual is %d", code, ws.closeCode)
+	}
+
+	return nil
+}
+
+// TheWebSocketShouldBeClosedWithCodeWithin Waits for the server to close the websocket, and checks the close code.
+// Messages received before are skipped.
+func (ctx *ApiContext) TheWebSocketShouldBeClosedWithCodeWithin(code int, seconds int) error {
+	ws := ctx.webSocket
+	if ws == nil {
+		return fmt.Errorf("no websocket is open")
+	}
+
+	if err := waitForWebSocketClose(ws, time.Duration(seconds)*time.Second); err != nil {
+		return err
+	}
+
+	ctx.closeWebSocket()
+
+	if ws.closeCode != code {
+		return fmt.Errorf("expected the websocket to be closed with code %d, but actual is %d: %v", code, ws.closeCode, ws.err)
+	}
+
+	return nil
+}
+
+// closeWebSocket Closes the websocket connection, if any. It is also called when the scenario ends.
+func (ctx *ApiContext) closeWebSocket() {
+	if ctx.webSocket == nil {
+		return
+	}
+
+	close(ctx.webSocket.done)
+	_ = ctx.webSocket.conn.Close()
+	ctx.webSocket = nil
+}
+
+// writeWebSocketMessage Sends a text message on the open websocket.
+func (ctx *ApiContext) writeWebSocketMessage(message string) error {
+	if ctx.webSocket == nil {
+		return fmt.Errorf("no websocket is open")
+	}
+
+	if ctx.debug {
+		log.Printf("websocket message sent: %s\n", message)
+	}
+
+	return ctx.webSocket.conn.WriteMessage(websocket.TextMessage, []byte(message))
+}
+
+// waitForWebSocketMessage Reads messages until one matches, or the timeout expires.
+func (ctx *ApiContext) waitForWebSocketMessage(seconds int, description string, match func(message string) bool) error {
+	ws := ctx.webSocket
+	if ws == nil {
+		return fmt.Errorf("no websocket is open")
+	}
+
+	timeout := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timeout.Stop()
+
+	var skipped []string
+	for {
+		select {
+		case message, ok := <-ws.messages:
+			if !ok {
+				return fmt.Errorf("the websocket was closed before receiving a message %s: %v", description, ws.err)
+			}
+
+			if !match(message) {
+				skipped = append(skipped, message)
+				continue
+			}
+
+			ws.lastMessage = &message
+
+			return nil
+		case <-timeout.C:
+			if len(skipped) > 0 {
+				return fmt.Errorf("no message %s received within %d seconds. received messages:\n%s", description, seconds, strings.Join(skipped, "\n"))
+			}
+
+			return fmt.Errorf("no message %s received within %d seconds", description, seconds)
+		}
+	}
+}
+
+// waitForWebSocketClose Waits for the websocket connection to end, skipping the remaining messages.
+func waitForWebSocketClose(ws *webSocket, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case _, ok := <-ws.messages:
+			if !ok {
+				return nil
+			}
+		case <-timer.C:
+			return fmt.Errorf("the websocket was not closed within %v", timeout)
+		}
+	}
+}
+
+// readWebSocketMessages Publishes the received messages until the connection ends.
+func (ctx *ApiContext) readWebSocketMessages(ws *webSocket) {
+	defer close(ws.messages)
+
+	for {
+		_, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				ws.closeCode = closeErr.Code
+			}
+
+			ws.err = err
+			return
+		}
+
+		if ctx.debug {
+			log.Printf("websocket message received: %s\n", data)
+		}
+
+		select {
+		case ws.messages <- string(data):
+		case <-ws.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package apicontext
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebSocketServer() *httptest.Server {
+	upgrader := websocket.Upgrader{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"type": "welcome", "tenant": %q, "room": %q}`, r.Header.Get("X-Tenant"), r.URL.Query().Get("room"))))
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if string(message) == "bye" {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(4000, "bye"), time.Now().Add(time.Second))
+				continue
+			}
+
+			_ = conn.WriteMessage(websocket.TextMessage, []byte("echo: "+string(message)))
+		}
+	}))
+}
+
+func TestApiContext_WebSocket(t *testing.T) {
+	ts := newWebSocketServer()
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	assert.Error(t, ctx.ISendWebSocketMessage("hello"))
+
+	assert.Nil(t, ctx.ISetHeaderWithValue("X-Tenant", "acme"))
+	assert.Nil(t, ctx.ISetQueryParamWithValue("room", "orders"))
+	assert.Nil(t, ctx.IConnectToWebSocket("/ws"))
+
+	assert.Nil(t, ctx.IShouldReceiveAWebSocketMessageWithJSONPathWithin("$.type", "welcome", 1))
+	assert.Nil(t, ctx.StoreWebSocketMessagePathValueIn("$.tenant", "tenant", "scenario"))
+	assert.Nil(t, ctx.TheScopeVariableShouldHaveValue("tenant", "acme"))
+
+	assert.Nil(t, ctx.ISendWebSocketMessage("hello `##tenant`"))
+	assert.Nil(t, ctx.IShouldReceiveAWebSocketMessageMatchingWithin("^echo: hello acme$", 1))
+
+	assert.Nil(t, ctx.ISendWebSocketMessageWithJSON(&godog.DocString{Content: `{"id": 1}`}))
+	assert.EqualError(t, ctx.IShouldReceiveAWebSocketMessageMatchingWithin("^pong$", 1),
+		"no message matching ^pong$ received within 1 seconds. received messages:\n"+`echo: {"id": 1}`)
+	assert.Error(t, ctx.ISendWebSocketMessageWithJSON(&godog.DocString{Content: `{"id": `}))
+
+	assert.Nil(t, ctx.ICloseTheWebSocketWithCode(websocket.CloseNormalClosure))
+	assert.Nil(t, ctx.webSocket)
+	assert.Error(t, ctx.ICloseTheWebSocketWithCode(websocket.CloseNormalClosure))
+}
+
+const webSocketFeature = `Feature: websocket
+  Scenario: chat
+    Given I set header "X-Tenant" with value "acme"
+    When I connect to websocket "/ws"
+    Then I should receive a websocket message with json path "$.tenant" having value "acme" within 2 seconds
+    When I send websocket message "bye"
+    Then The websocket should be closed with code 4000 within 2 seconds
+`
+
+func TestApiContext_WebSocketSteps(t *testing.T) {
+	ts := newWebSocketServer()
+
+	defer ts.Close()
+	ctx := setupTestContext().
+		WithBaseURL(ts.URL).
+		WithDebug(false)
+
+	status, _ := runTestSuite(t, ctx, webSocketFeature)
+	assert.Equal(t, 0, status)
+}